@@ -0,0 +1,30 @@
+package api
+
+import "time"
+
+// ContactStatus records how far along reaching out to a therapist is.
+type ContactStatus string
+
+const (
+	NotContacted  ContactStatus = "not_contacted"
+	LeftVoicemail ContactStatus = "left_voicemail"
+	Scheduled     ContactStatus = "scheduled"
+	Declined      ContactStatus = "declined"
+)
+
+// Valid reports whether the status is one of the known contact statuses.
+func (s ContactStatus) Valid() bool {
+	switch s {
+	case NotContacted, LeftVoicemail, Scheduled, Declined:
+		return true
+	}
+	return false
+}
+
+// Note is a free text note a user has written about a therapist.
+type Note struct {
+	ID          int       `bun:"id,pk,autoincrement" json:"id"`
+	TherapistID int       `json:"therapist_id"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+}
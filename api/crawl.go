@@ -0,0 +1,38 @@
+package api
+
+import "time"
+
+// Crawl is a fetch of one search, whose queue of pages is kept so the fetch
+// can be resumed if it's interrupted.
+type Crawl struct {
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+	// Region is the search results URL the crawl started from.
+	Region    string    `bun:"region,notnull" json:"region"`
+	Source    string    `bun:"source,notnull" json:"source"`
+	StartedAt time.Time `bun:"started_at,notnull" json:"started_at"`
+	// FinishedAt is set once every search results page has been read.
+	FinishedAt time.Time `bun:"finished_at,nullzero" json:"finished_at"`
+}
+
+// PageState is how far a crawl has got with a page.
+type PageState string
+
+const (
+	PagePending PageState = "pending"
+	PageDone    PageState = "done"
+	PageFailed  PageState = "failed"
+)
+
+// CrawlPage is a page queued in a crawl.
+type CrawlPage struct {
+	ID      int    `bun:"id,pk,autoincrement" json:"id"`
+	CrawlID int    `bun:"crawl_id,notnull" json:"crawl_id"`
+	URL     string `bun:"url,notnull" json:"url"`
+	// Profile is set when the page is a therapist's profile rather than a
+	// search results page.
+	Profile bool `bun:"profile,notnull" json:"profile"`
+	// Request is the serialized request the crawler queued.
+	Request   []byte    `bun:"request" json:"-"`
+	State     PageState `bun:"state,notnull" json:"state"`
+	UpdatedAt time.Time `bun:"updated_at,notnull" json:"updated_at"`
+}
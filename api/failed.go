@@ -0,0 +1,19 @@
+package api
+
+import "time"
+
+// FailedPage is a page that still failed to fetch after every retry. It's
+// kept so the page can be fetched again later.
+type FailedPage struct {
+	ID  int    `bun:"id,pk,autoincrement" json:"id"`
+	URL string `bun:"url,unique" json:"url"`
+	// Region is the search the page was fetched as part of.
+	Region string `bun:"region,notnull" json:"region"`
+	Source string `bun:"source,notnull" json:"source"`
+	// Profile is set when the page was a therapist's profile rather than a
+	// search results page.
+	Profile  bool      `bun:"profile,notnull" json:"profile"`
+	Error    string    `bun:"error,notnull" json:"error"`
+	Attempts int       `bun:"attempts,notnull" json:"attempts"`
+	FailedAt time.Time `bun:"failed_at,notnull" json:"failed_at"`
+}
@@ -0,0 +1,58 @@
+package api
+
+// OrderField is a therapist field results can be sorted by.
+type OrderField string
+
+const (
+	OrderByTitle       OrderField = "title"
+	OrderByCredentials OrderField = "credentials"
+	OrderByLastSeen    OrderField = "last_seen"
+	// OrderByDistance requires a near filter.
+	OrderByDistance OrderField = "distance"
+	// OrderByRelevance requires a search filter. Descending puts the best
+	// matches first.
+	OrderByRelevance OrderField = "relevance"
+)
+
+// Direction is the direction results are sorted in.
+type Direction string
+
+const (
+	Ascending  Direction = "asc"
+	Descending Direction = "desc"
+)
+
+// OrderBy sorts results by a field. Ties are broken by therapist ID so the
+// order is always stable.
+type OrderBy struct {
+	Field     OrderField `json:"field"`
+	Direction Direction  `json:"direction"`
+}
+
+// Page selects a slice of results relative to opaque cursors, following the
+// Relay connection specification. First and After page forwards, Last and
+// Before page backwards.
+type Page struct {
+	First  *int    `json:"first"`
+	After  *string `json:"after"`
+	Last   *int    `json:"last"`
+	Before *string `json:"before"`
+}
+
+type TherapistEdge struct {
+	Node   Therapist `json:"node"`
+	Cursor string    `json:"cursor"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type TherapistConnection struct {
+	Edges      []TherapistEdge `json:"edges"`
+	PageInfo   PageInfo        `json:"pageInfo"`
+	TotalCount int             `json:"totalCount"`
+}
@@ -0,0 +1,20 @@
+package api
+
+import "time"
+
+// Revision is a change to a therapist's details found when they were saved
+// again.
+type Revision struct {
+	ID          int           `json:"id"`
+	TherapistID int           `json:"therapist_id"`
+	ChangedAt   time.Time     `json:"changed_at"`
+	Changes     []FieldChange `json:"changes"`
+}
+
+// FieldChange is the old and new value of a changed field. Lists are joined
+// with "; ".
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
@@ -0,0 +1,36 @@
+package api
+
+import "time"
+
+// CrawlRun records one run of fetch, what it fetched and how it changed the
+// therapists it found.
+type CrawlRun struct {
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+	// CrawlID is the crawl the run fetched. Runs resuming a crawl share its
+	// ID, and runs fetching failed pages again have none.
+	CrawlID int `bun:"crawl_id,nullzero" json:"crawl_id"`
+	// Region is the search results URL the run fetched.
+	Region string `bun:"region,notnull" json:"region"`
+	Source string `bun:"source,notnull" json:"source"`
+	// Params are the flags fetch was run with.
+	Params     []Param   `bun:"params" json:"params"`
+	StartedAt  time.Time `bun:"started_at,notnull" json:"started_at"`
+	FinishedAt time.Time `bun:"finished_at,nullzero" json:"finished_at"`
+	Pages      int       `bun:"pages,notnull" json:"pages"`
+	Failed     int       `bun:"failed,notnull" json:"failed"`
+	New        int       `bun:"new,notnull" json:"new"`
+	Updated    int       `bun:"updated,notnull" json:"updated"`
+	Unchanged  int       `bun:"unchanged,notnull" json:"unchanged"`
+	// Vanished is the number of therapists marked stale because the run
+	// no longer found them.
+	Vanished int      `bun:"vanished,notnull" json:"vanished"`
+	Errors   []string `bun:"errors" json:"errors"`
+	// Complete is set when every search results page was read.
+	Complete bool `bun:"complete,notnull" json:"complete"`
+}
+
+// Param is a flag a fetch was run with.
+type Param struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
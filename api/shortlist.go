@@ -0,0 +1,11 @@
+package api
+
+import "time"
+
+// Shortlist is a named list of therapists a user has collected.
+type Shortlist struct {
+	ID        int       `bun:"id,pk,autoincrement" json:"id"`
+	Name      string    `bun:"name,unique" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int       `bun:"size,scanonly" json:"size"`
+}
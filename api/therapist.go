@@ -1,26 +1,125 @@
 package api
 
+import "time"
+
 type Therapist struct {
-	ID                    int    `bun:"id,pk,autoincrement" json:"id"`
-	Title                 string `json:"title"`
-	AcceptingAppointments string `json:"accepting_appointments"`
-	Credentials           string `json:"credentials"`
-	Verified              string `json:"verified"`
-	Statement             string `json:"statement"`
-	Phone                 string `json:"phone"`
-	Location              string `json:"location"`
-	Link                  string `json:"link"`
+	ID                    int           `bun:"id,pk,autoincrement" json:"id"`
+	Title                 string        `json:"title"`
+	AcceptingAppointments bool          `bun:",notnull" json:"accepting_appointments"`
+	Credentials           string        `json:"credentials"`
+	Verified              string        `json:"verified"`
+	Statement             string        `json:"statement"`
+	Phone                 string        `json:"phone"`
+	Location              string        `json:"location"`
+	Link                  string        `json:"link"`
+	Insurance             []string      `bun:"-" json:"insurance"`
+	Fees                  string        `json:"fees"`
+	InPerson              bool          `bun:",notnull" json:"in_person"`
+	Telehealth            bool          `bun:",notnull" json:"telehealth"`
+	Specialties           []string      `bun:"-" json:"specialties"`
+	Issues                []string      `bun:"-" json:"issues"`
+	Modalities            []string      `bun:"-" json:"modalities"`
+	AgeGroups             []string      `bun:"-" json:"age_groups"`
+	Languages             []string      `bun:"-" json:"languages"`
+	Tags                  []string      `bun:"-" json:"tags"`
+	Rating                *int          `bun:"-" json:"rating"`
+	ContactStatus         ContactStatus `bun:"-" json:"contact_status"`
+	Notes                 []Note        `bun:"-" json:"notes"`
+	Latitude              *float64      `json:"latitude"`
+	Longitude             *float64      `json:"longitude"`
+	Distance              *float64      `bun:",scanonly" json:"distance,omitempty"`
+	Relevance             *float64      `bun:",scanonly" json:"relevance,omitempty"`
+	Region                string        `json:"region"`
+	Source                string        `bun:",notnull" json:"source"`
+	FirstSeenAt           time.Time     `bun:",nullzero" json:"first_seen_at"`
+	LastSeenAt            time.Time     `bun:",nullzero" json:"last_seen_at"`
+	Stale                 bool          `bun:",notnull" json:"stale"`
+	// Profiled reports whether the details only shown on the therapist's
+	// profile, such as their statement, fees and session formats, were read.
+	// Saving a therapist that wasn't profiled keeps those already saved.
+	Profiled bool `bun:"-" json:"-"`
+	// Fields names the details that were read, by their JSON name, when
+	// only some of them were, such as a file missing some columns. Saving a
+	// therapist with Fields set keeps the saved values of the others.
+	Fields []string `bun:"-" json:"-"`
+}
+
+// SaveResult counts how saving a batch of therapists changed them.
+type SaveResult struct {
+	New int
+	// Updated is the number of therapists already saved whose details
+	// changed, and Unchanged the number whose details didn't.
+	Updated   int
+	Unchanged int
 }
 
 type GetTherapistParams struct {
-	Title                 *string `json:"title"`
-	Credentials           *string `json:"credentials"`
-	AcceptingAppointments *bool   `json:"accepting_appointments"`
-	Verified              *string `json:"verified"`
-	Statement             *string `json:"statement"`
-	Phone                 *string `json:"phone"`
-	Location              *string `json:"location"`
-	Link                  *string `json:"link"`
-	Limit                 *int    `json:"limit"`
-	Offset                *int    `json:"offset"`
+	Title                 *string         `json:"title,omitempty"`
+	Credentials           *string         `json:"credentials,omitempty"`
+	AcceptingAppointments *bool           `json:"accepting_appointments,omitempty"`
+	Verified              *string         `json:"verified,omitempty"`
+	Statement             *string         `json:"statement,omitempty"`
+	Phone                 *string         `json:"phone,omitempty"`
+	Location              *string         `json:"location,omitempty"`
+	Link                  *string         `json:"link,omitempty"`
+	Search                *string         `json:"search,omitempty"`
+	Specialties           *ListFilter     `json:"specialties,omitempty"`
+	Issues                *ListFilter     `json:"issues,omitempty"`
+	Modalities            *ListFilter     `json:"modalities,omitempty"`
+	AgeGroups             *ListFilter     `json:"age_groups,omitempty"`
+	Languages             *ListFilter     `json:"languages,omitempty"`
+	Insurance             *ListFilter     `json:"insurance,omitempty"`
+	Tags                  *ListFilter     `json:"tags,omitempty"`
+	MinRating             *int            `json:"min_rating,omitempty"`
+	ContactStatus         []ContactStatus `json:"contact_status,omitempty"`
+	Shortlist             *string         `json:"shortlist,omitempty"`
+	Near                  *Near           `json:"near,omitempty"`
+	InPerson              *bool           `json:"in_person,omitempty"`
+	Telehealth            *bool           `json:"telehealth,omitempty"`
+	Region                *string         `json:"region,omitempty"`
+	Source                *string         `json:"source,omitempty"`
+	Stale                 *bool           `json:"stale,omitempty"`
+	FirstSeenAfter        *time.Time      `json:"first_seen_after,omitempty"`
+	FirstSeenBefore       *time.Time      `json:"first_seen_before,omitempty"`
+	LastSeenAfter         *time.Time      `json:"last_seen_after,omitempty"`
+	LastSeenBefore        *time.Time      `json:"last_seen_before,omitempty"`
+	OrderBy               *OrderBy        `json:"order_by,omitempty"`
+	Limit                 *int            `json:"limit,omitempty"`
+	Offset                *int            `json:"offset,omitempty"`
+}
+
+// Near restricts results to therapists within a radius of a point, given
+// either as a zip code or as a latitude and longitude. Matching therapists
+// are sorted nearest first.
+type Near struct {
+	Zip         *string  `json:"zip"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	RadiusMiles float64  `json:"radius_miles"`
+}
+
+// Match controls how the values of a ListFilter are combined.
+type Match string
+
+const (
+	// MatchAny matches therapists with at least one of the values.
+	MatchAny Match = "any"
+	// MatchAll matches therapists with every one of the values.
+	MatchAll Match = "all"
+)
+
+// ListFilter filters on a list-valued attribute such as specialties or
+// insurance. Each value is matched case-insensitively as a substring.
+type ListFilter struct {
+	Values []string `json:"values"`
+	Match  Match    `json:"match"`
+}
+
+// SearchResult is a therapist matched by a full-text search along with a
+// highlighted snippet of the matching text and its BM25 rank, where lower
+// ranks are better matches.
+type SearchResult struct {
+	Therapist Therapist `json:"therapist"`
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
 }
@@ -0,0 +1,34 @@
+package api
+
+import "time"
+
+// SavedSearch is a named therapist filter that is re-run after each fetch to
+// report how its matches have changed.
+type SavedSearch struct {
+	ID        int                `bun:"id,pk,autoincrement" json:"id"`
+	Name      string             `bun:"name,unique" json:"name"`
+	Params    GetTherapistParams `bun:"params,notnull" json:"params"`
+	CreatedAt time.Time          `json:"created_at"`
+	LastRunAt time.Time          `bun:",nullzero" json:"last_run_at"`
+}
+
+// SearchDiff describes how the matches of a saved search changed since it
+// was last run.
+type SearchDiff struct {
+	Search  string            `json:"search"`
+	Added   []Therapist       `json:"added"`
+	Removed []Therapist       `json:"removed"`
+	Changed []AcceptingChange `json:"changed"`
+}
+
+// Empty reports whether nothing changed.
+func (d SearchDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// AcceptingChange records a matching therapist whose accepting appointments
+// status changed.
+type AcceptingChange struct {
+	Therapist Therapist `json:"therapist"`
+	Was       bool      `json:"was"`
+}
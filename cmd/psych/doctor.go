@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/brittonhayes/therapy/fetch"
+	"github.com/urfave/cli/v2"
+)
+
+// doctor runs the source's parser over a cached search results page, the
+// further results pages and profiles cached with it, and reports which fields
+// came back empty. It fails if a required field was empty everywhere.
+func doctor(c *cli.Context) error {
+	source, selectors, err := selectedSource(c)
+	if err != nil {
+		return err
+	}
+
+	sel := source.Selectors()
+	if selectors != nil {
+		sel = *selectors
+	}
+
+	cacheDir := filepath.Join(c.String("config"), "cache/")
+
+	pageURL := c.Args().First()
+	if pageURL == "" {
+		pageURL, err = source.URL(query(c))
+		if err != nil && !c.IsSet("file") {
+			return fmt.Errorf("%w, or pass the url of a cached page", err)
+		}
+		if err != nil {
+			// Links on a saved page are resolved against the site.
+			pageURL = "https://" + source.Domains()[0] + "/"
+		}
+	}
+
+	var pages []fetch.Page
+	if c.IsSet("file") {
+		b, err := os.ReadFile(c.String("file"))
+		if err != nil {
+			return err
+		}
+		pages = append(pages, fetch.Page{URL: pageURL, Body: b})
+	} else {
+		page, err := fetch.CachedPage(cacheDir, pageURL)
+		if errors.Is(err, fetch.ErrNotCached) {
+			return fmt.Errorf("%w, run psych fetch with the same flags first", err)
+		}
+		if err != nil {
+			return err
+		}
+
+		pages, err = cachedResults(cacheDir, source, sel, page)
+		if err != nil {
+			return err
+		}
+	}
+
+	results, err := fetch.CheckResults(source, sel, pages...)
+	if err != nil {
+		return err
+	}
+
+	out := c.App.Writer
+	fmt.Fprintf(out, "Search results: %d pages, %d results\n", results.Pages, results.Total)
+	if results.Total == 0 {
+		return fmt.Errorf("results selector %q matched nothing, the site's markup may have changed", sel.Results)
+	}
+
+	empty := printFields(out, "result", results)
+
+	var profiles []fetch.Page
+	for _, link := range results.Profiles {
+		page, err := fetch.CachedPage(cacheDir, link)
+		if err != nil {
+			continue
+		}
+		profiles = append(profiles, page)
+	}
+
+	fmt.Fprintf(out, "\nProfiles: %d of %d cached\n", len(profiles), len(results.Profiles))
+	if len(profiles) > 0 {
+		report, err := fetch.CheckProfiles(sel, profiles...)
+		if err != nil {
+			return err
+		}
+
+		empty = append(empty, printFields(out, "profile", report)...)
+	}
+
+	if len(empty) > 0 {
+		return fmt.Errorf("required fields came back empty: %s", strings.Join(empty, ", "))
+	}
+
+	return nil
+}
+
+// cachedResults follows the pagination of a cached search results page
+// through every further page that is also cached.
+func cachedResults(cacheDir string, source fetch.Source, sel fetch.Selectors, first fetch.Page) ([]fetch.Page, error) {
+	pages := []fetch.Page{first}
+	seen := map[string]bool{first.URL: true}
+
+	for i := 0; i < len(pages); i++ {
+		report, err := fetch.CheckResults(source, sel, pages[i])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, next := range report.Next {
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+
+			page, err := fetch.CachedPage(cacheDir, next)
+			if err != nil {
+				continue
+			}
+			pages = append(pages, page)
+		}
+	}
+
+	return pages, nil
+}
+
+// printFields prints how often each field was found, and returns the
+// required fields that were never found, prefixed with group.
+func printFields(out io.Writer, group string, report fetch.Report) []string {
+	var empty []string
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  FIELD\tREQUIRED\tFOUND\t")
+	for _, f := range report.Fields {
+		status := ""
+		if f.Empty() {
+			status = "empty"
+			if f.Selector.Required {
+				empty = append(empty, group+"."+f.Field)
+			}
+		}
+
+		fmt.Fprintf(w, "  %s\t%s\t%d/%d\t%s\n", f.Field, yesNo(f.Selector.Required), f.Found, report.Total, status)
+	}
+	w.Flush()
+
+	return empty
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/brittonhayes/therapy"
+	"github.com/brittonhayes/therapy/api"
+	"github.com/brittonhayes/therapy/fetch"
+	"github.com/urfave/cli/v2"
+)
+
+// logStats logs the summary of a finished fetch.
+func logStats(ctx context.Context, logger *slog.Logger, stats fetch.Stats) {
+	logger.InfoContext(ctx, "Fetch finished",
+		slog.Int("pages", stats.Pages),
+		slog.Int("retried", stats.Retried),
+		slog.Int("failed", stats.Failed),
+		slog.Int("blocked", stats.Blocked),
+		slog.Int("saved", stats.Saved),
+		slog.Int("new", stats.New),
+		slog.Int("updated", stats.Updated),
+		slog.Duration("duration", stats.Duration.Round(time.Millisecond)),
+		slog.String("throughput", fmt.Sprintf("%.1f pages/s", stats.Throughput())),
+	)
+}
+
+// startRun records the start of a fetch of config.URL in the crawl runs.
+func startRun(c *cli.Context, repo therapy.Repository, config fetch.Config) (api.CrawlRun, error) {
+	var params []api.Param
+	for _, flag := range c.Command.Flags {
+		category, ok := flag.(cli.CategorizableFlag)
+		if !ok || (category.GetCategory() != "Fetching" && category.GetCategory() != "Crawling") {
+			continue
+		}
+
+		name := flag.Names()[0]
+		if c.IsSet(name) {
+			params = append(params, api.Param{Name: name, Value: fmt.Sprint(c.Value(name))})
+		}
+	}
+
+	return repo.CreateCrawlRun(c.Context, api.CrawlRun{
+		CrawlID: config.Crawl,
+		Region:  config.URL,
+		Source:  config.Source.Name(),
+		Params:  params,
+	})
+}
+
+// finishRun records how a fetch ended. A run that can't be saved is only
+// logged, so it doesn't hide how the fetch itself went.
+func finishRun(ctx context.Context, logger *slog.Logger, repo therapy.Repository, run api.CrawlRun, stats fetch.Stats, vanished int, err error) {
+	run.FinishedAt = time.Now()
+	run.Pages = stats.Pages
+	run.Failed = stats.Failed
+	run.New = stats.New
+	run.Updated = stats.Updated
+	run.Unchanged = stats.Unchanged
+	run.Vanished = vanished
+	run.Complete = fetch.Complete(err)
+
+	if err != nil {
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+
+		for _, err := range errs {
+			run.Errors = append(run.Errors, err.Error())
+		}
+	}
+
+	// The run is saved even when the fetch was interrupted.
+	err = repo.UpdateCrawlRun(context.WithoutCancel(ctx), run)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to save crawl run", slog.Int("run", run.ID), slog.String("error", err.Error()))
+	}
+}
+
+// resumeCrawl sets config up to continue the most recent unfinished crawl,
+// or the most recent one of the search given with the location flags.
+func resumeCrawl(c *cli.Context, logger *slog.Logger, repo therapy.Repository, config *fetch.Config) (api.Crawl, error) {
+	var region string
+	for _, name := range []string{"state", "county", "city", "zip"} {
+		if !c.IsSet(name) {
+			continue
+		}
+
+		url, err := config.Source.URL(query(c))
+		if err != nil {
+			return api.Crawl{}, err
+		}
+		region = url
+		break
+	}
+
+	crawl, err := repo.UnfinishedCrawl(c.Context, region)
+	if err != nil {
+		return crawl, fmt.Errorf("%w to resume", err)
+	}
+
+	source, err := fetch.Lookup(crawl.Source)
+	if err != nil {
+		return crawl, err
+	}
+
+	if source.Name() != config.Source.Name() {
+		config.Selectors = nil
+	}
+	config.Source = source
+	config.URL = crawl.Region
+
+	logger.InfoContext(c.Context, "Resuming fetch",
+		slog.String("region", crawl.Region),
+		slog.Time("started_at", crawl.StartedAt),
+	)
+
+	return crawl, nil
+}
+
+// resumeFailed fetches the pages that failed after every retry in earlier
+// fetches again, one fetch per search they were part of. Only some pages of
+// each search are fetched, so nobody is marked stale.
+func resumeFailed(c *cli.Context, logger *slog.Logger, repo therapy.Repository, config fetch.Config) error {
+	pages, err := repo.FailedPages(c.Context)
+	if err != nil {
+		return err
+	}
+
+	if len(pages) == 0 {
+		logger.InfoContext(c.Context, "No failed pages to fetch again")
+		return nil
+	}
+
+	type search struct{ source, region string }
+	var searches []search
+	failed := map[search][]api.FailedPage{}
+	for _, page := range pages {
+		key := search{source: page.Source, region: page.Region}
+		if _, ok := failed[key]; !ok {
+			searches = append(searches, key)
+		}
+		failed[key] = append(failed[key], page)
+	}
+
+	var errs []error
+	s := fetch.NewFetcher(logger, repo)
+	for _, key := range searches {
+		source, err := fetch.Lookup(key.source)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		cfg := config
+		cfg.URL = key.region
+		cfg.Failed = failed[key]
+		// A selector profile given with --selectors only applies to its own
+		// source.
+		if source.Name() != config.Source.Name() {
+			cfg.Selectors = nil
+		}
+		cfg.Source = source
+
+		run, err := startRun(c, repo, cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		logger.InfoContext(c.Context, "Fetching failed pages again", slog.String("region", key.region), slog.Int("count", len(cfg.Failed)))
+		stats, err := s.Fetch(c.Context, cfg)
+		logStats(c.Context, logger, stats)
+		finishRun(c.Context, logger, repo, run, stats, 0, err)
+		errs = append(errs, err)
+
+		if c.Context.Err() != nil {
+			return errors.Join(errs...)
+		}
+	}
+
+	diffs, err := runSavedSearches(c.Context, repo)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	err = report(c, diffs)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	return errors.Join(errs...)
+}
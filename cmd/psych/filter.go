@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/urfave/cli/v2"
+)
+
+// filterFlags returns the flags used to build therapist query parameters.
+func filterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "title", Usage: "Name starts with", Category: "Filtering"},
+		&cli.StringFlag{Name: "credentials", Usage: "Credentials contain (e.g. 'LMFT')", Category: "Filtering"},
+		&cli.BoolFlag{Name: "accepting", Usage: "Accepting new clients", Category: "Filtering"},
+		&cli.StringFlag{Name: "verified", Usage: "Verification badge contains", Category: "Filtering"},
+		&cli.StringFlag{Name: "statement", Usage: "Statement contains", Category: "Filtering"},
+		&cli.StringFlag{Name: "phone", Usage: "Phone number contains", Category: "Filtering"},
+		&cli.StringFlag{Name: "location", Usage: "Location contains", Category: "Filtering"},
+		&cli.StringFlag{Name: "link", Usage: "Profile link is exactly", Category: "Filtering"},
+		&cli.StringFlag{Name: "search", Usage: "Full-text search terms", Category: "Filtering"},
+		&cli.StringSliceFlag{Name: "specialty", Usage: "Specialty to match, repeatable", Category: "Filtering"},
+		&cli.StringSliceFlag{Name: "issue", Usage: "Issue treated to match, repeatable", Category: "Filtering"},
+		&cli.StringSliceFlag{Name: "modality", Usage: "Therapy type to match, repeatable", Category: "Filtering"},
+		&cli.StringSliceFlag{Name: "age-group", Usage: "Age group to match, repeatable", Category: "Filtering"},
+		&cli.StringSliceFlag{Name: "language", Usage: "Language to match, repeatable", Category: "Filtering"},
+		&cli.StringSliceFlag{Name: "insurance", Usage: "Insurance to match, repeatable", Category: "Filtering"},
+		&cli.StringSliceFlag{Name: "tag", Usage: "Tag to match, repeatable", Category: "Filtering"},
+		&cli.StringFlag{Name: "match", Usage: "Whether list filters match 'any' or 'all' of their values", Value: string(api.MatchAny), Category: "Filtering"},
+		&cli.StringFlag{Name: "near", Usage: "Zip code to search around", Category: "Filtering"},
+		&cli.Float64Flag{Name: "radius", Usage: "Search radius in miles around --near", Value: 10, Category: "Filtering"},
+		&cli.BoolFlag{Name: "in-person", Usage: "Offers in-person sessions", Category: "Filtering"},
+		&cli.BoolFlag{Name: "telehealth", Usage: "Offers telehealth sessions", Category: "Filtering"},
+		&cli.StringFlag{Name: "region", Usage: "Fetched from this search URL", Category: "Filtering"},
+		&cli.StringFlag{Name: "source", Usage: "Came from this source (e.g. 'psychologytoday')", Category: "Filtering"},
+		&cli.BoolFlag{Name: "stale", Usage: "No longer listed", Category: "Filtering"},
+		&cli.StringFlag{Name: "first-seen-after", Usage: "First seen on or after this date or RFC 3339 time", Category: "Filtering"},
+		&cli.StringFlag{Name: "first-seen-before", Usage: "First seen before this date or RFC 3339 time", Category: "Filtering"},
+		&cli.StringFlag{Name: "last-seen-after", Usage: "Last seen on or after this date or RFC 3339 time", Category: "Filtering"},
+		&cli.StringFlag{Name: "last-seen-before", Usage: "Last seen before this date or RFC 3339 time", Category: "Filtering"},
+		&cli.IntFlag{Name: "min-rating", Usage: "Rated at least this many stars", Category: "Filtering"},
+		&cli.StringSliceFlag{Name: "contact-status", Usage: "Contact status to match, repeatable (not_contacted, left_voicemail, scheduled, declined)", Category: "Filtering"},
+		&cli.StringFlag{Name: "shortlist", Usage: "On the shortlist with this name", Category: "Filtering"},
+		&cli.StringFlag{Name: "order", Usage: "Sort by title, credentials, last_seen, distance (with --near) or relevance (with --search)", Category: "Filtering"},
+		&cli.BoolFlag{Name: "desc", Usage: "Sort --order in descending order", Category: "Filtering"},
+		&cli.IntFlag{Name: "limit", Usage: "Maximum number of therapists", Category: "Filtering"},
+		&cli.IntFlag{Name: "offset", Usage: "Number of therapists to skip", Category: "Filtering"},
+	}
+}
+
+// orderFields are the values --order accepts.
+var orderFields = []api.OrderField{
+	api.OrderByTitle,
+	api.OrderByCredentials,
+	api.OrderByLastSeen,
+	api.OrderByDistance,
+	api.OrderByRelevance,
+}
+
+// filterParams builds therapist query parameters from the flags returned by
+// filterFlags. Flags that weren't set are left out.
+func filterParams(c *cli.Context) (api.GetTherapistParams, error) {
+	var params api.GetTherapistParams
+
+	str := func(name string) *string {
+		if !c.IsSet(name) {
+			return nil
+		}
+		v := c.String(name)
+		return &v
+	}
+
+	boolean := func(name string) *bool {
+		if !c.IsSet(name) {
+			return nil
+		}
+		v := c.Bool(name)
+		return &v
+	}
+
+	integer := func(name string) *int {
+		if !c.IsSet(name) {
+			return nil
+		}
+		v := c.Int(name)
+		return &v
+	}
+
+	match := api.Match(c.String("match"))
+	if match != api.MatchAny && match != api.MatchAll {
+		return params, fmt.Errorf("invalid --match %q, must be 'any' or 'all'", match)
+	}
+
+	date := func(name string) (*time.Time, error) {
+		if !c.IsSet(name) {
+			return nil, nil
+		}
+
+		v := c.String(name)
+		for _, layout := range []string{time.DateOnly, time.RFC3339} {
+			t, err := time.ParseInLocation(layout, v, time.Local)
+			if err == nil {
+				return &t, nil
+			}
+		}
+
+		return nil, fmt.Errorf("invalid --%s %q, must be a date like 2024-06-01 or an RFC 3339 time", name, v)
+	}
+
+	list := func(name string) *api.ListFilter {
+		if !c.IsSet(name) {
+			return nil
+		}
+		return &api.ListFilter{Values: c.StringSlice(name), Match: match}
+	}
+
+	params.Title = str("title")
+	params.Credentials = str("credentials")
+	params.AcceptingAppointments = boolean("accepting")
+	params.Verified = str("verified")
+	params.Statement = str("statement")
+	params.Phone = str("phone")
+	params.Location = str("location")
+	params.Link = str("link")
+	params.Search = str("search")
+	params.Specialties = list("specialty")
+	params.Issues = list("issue")
+	params.Modalities = list("modality")
+	params.AgeGroups = list("age-group")
+	params.Languages = list("language")
+	params.Insurance = list("insurance")
+	params.Tags = list("tag")
+	params.InPerson = boolean("in-person")
+	params.Telehealth = boolean("telehealth")
+	params.Region = str("region")
+	params.Source = str("source")
+	params.Stale = boolean("stale")
+	params.MinRating = integer("min-rating")
+	params.Shortlist = str("shortlist")
+	params.Limit = integer("limit")
+	params.Offset = integer("offset")
+
+	var err error
+	for _, d := range []struct {
+		name string
+		t    **time.Time
+	}{
+		{"first-seen-after", &params.FirstSeenAfter},
+		{"first-seen-before", &params.FirstSeenBefore},
+		{"last-seen-after", &params.LastSeenAfter},
+		{"last-seen-before", &params.LastSeenBefore},
+	} {
+		*d.t, err = date(d.name)
+		if err != nil {
+			return params, err
+		}
+	}
+
+	if c.IsSet("order") {
+		field := api.OrderField(c.String("order"))
+		if !slices.Contains(orderFields, field) {
+			return params, fmt.Errorf("invalid --order %q, must be title, credentials, last_seen, distance or relevance", field)
+		}
+
+		params.OrderBy = &api.OrderBy{Field: field, Direction: api.Ascending}
+		if c.Bool("desc") {
+			params.OrderBy.Direction = api.Descending
+		}
+	}
+
+	if c.IsSet("near") {
+		params.Near = &api.Near{Zip: str("near"), RadiusMiles: c.Float64("radius")}
+	}
+
+	for _, s := range c.StringSlice("contact-status") {
+		status := api.ContactStatus(s)
+		if !status.Valid() {
+			return params, fmt.Errorf("invalid --contact-status %q", s)
+		}
+		params.ContactStatus = append(params.ContactStatus, status)
+	}
+
+	return params, nil
+}
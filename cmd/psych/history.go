@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+// printHistory prints a timeline of a therapist's details changing, from
+// when they were first seen.
+func printHistory(out io.Writer, therapist api.Therapist, history []api.Revision) {
+	fmt.Fprintf(out, "%s (%d)\n\n", therapist.Title, therapist.ID)
+	fmt.Fprintf(out, "%s  first seen\n", therapist.FirstSeenAt.Local().Format(time.DateTime))
+
+	for _, rev := range history {
+		fmt.Fprintf(out, "%s  changed\n", rev.ChangedAt.Local().Format(time.DateTime))
+		for _, change := range rev.Changes {
+			fmt.Fprintf(out, "  %s\n", change.Field)
+			fmt.Fprintf(out, "    - %s\n", change.Old)
+			fmt.Fprintf(out, "    + %s\n", change.New)
+		}
+	}
+
+	fmt.Fprintf(out, "%s  last seen", therapist.LastSeenAt.Local().Format(time.DateTime))
+	if therapist.Stale {
+		fmt.Fprint(out, ", no longer listed")
+	}
+	fmt.Fprintln(out)
+}
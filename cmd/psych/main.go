@@ -2,16 +2,20 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
-	"net/url"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"os"
 
@@ -21,8 +25,10 @@ import (
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/brittonhayes/therapy"
 	"github.com/brittonhayes/therapy/api"
+	"github.com/brittonhayes/therapy/export"
 	"github.com/brittonhayes/therapy/fetch"
 	"github.com/brittonhayes/therapy/graph"
+	"github.com/brittonhayes/therapy/importer"
 	"github.com/brittonhayes/therapy/sqlite"
 	"github.com/brittonhayes/therapy/tui"
 	"github.com/urfave/cli/v2"
@@ -30,10 +36,6 @@ import (
 
 var Version = "development"
 
-const (
-	ErrNotEnoughFlags = "not enough flags provided to generate web scraping URL"
-)
-
 func main() {
 	var (
 		repo   therapy.Repository
@@ -71,6 +73,29 @@ func main() {
 		},
 	}
 
+	openRepository := func(c *cli.Context) error {
+		if _, err := os.Stat(c.String("config")); err != nil {
+			err := os.MkdirAll(c.String("config"), fs.ModePerm)
+			if err != nil {
+				return err
+			}
+		}
+
+		repo = sqlite.NewRepository(c.String("db"), logger)
+
+		err := repo.Init(context.Background())
+		if err != nil {
+			return err
+		}
+
+		err = repo.Migrate(context.Background())
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
 	app := &cli.App{
 		Name:        "psych",
 		Description: "Find a mental health professional",
@@ -117,56 +142,51 @@ func main() {
 			{
 				Name:  "fetch",
 				Usage: "Fetch the latest therapists from the web",
-				Flags: append(globalFlags,
-					&cli.StringFlag{
-						Name:     "state",
-						Usage:    "State to search",
-						Value:    "",
-						Category: "Fetching",
+				Flags: append(append(append(globalFlags, sourceFlags()...),
+					&cli.IntFlag{
+						Name:     "parallelism",
+						Usage:    "Most requests to make to the directory at once",
+						Value:    4,
+						Category: "Crawling",
 					},
-					&cli.StringFlag{
-						Name:     "country",
-						Usage:    "Country to search",
-						Value:    "us",
-						Category: "Fetching",
-						// Temporarily hidden until supported
-						Hidden: true,
-						Action: func(ctx *cli.Context, s string) error {
-							if s != "us" && s != "ca" {
-								return errors.New("only us or ca are supported at this time")
-							}
-							return nil
-						},
+					&cli.DurationFlag{
+						Name:     "delay",
+						Usage:    "Time to wait between requests to the directory",
+						Value:    500 * time.Millisecond,
+						Category: "Crawling",
 					},
-					&cli.StringFlag{
-						Name:     "city",
-						Usage:    "City to search",
-						Value:    "",
-						Category: "Fetching",
+					&cli.DurationFlag{
+						Name:     "random-delay",
+						Usage:    "Most extra time to wait between requests, picked at random for each one",
+						Value:    500 * time.Millisecond,
+						Category: "Crawling",
 					},
-					&cli.StringFlag{
-						Name:     "zip",
-						Usage:    "Zip code to search",
-						Value:    "",
-						Category: "Fetching",
+					&cli.BoolFlag{
+						Name:     "respect-robots",
+						Usage:    "Skip pages the directory's robots.txt disallows",
+						Category: "Crawling",
 					},
-					&cli.StringFlag{
-						Name:     "county",
-						Usage:    "County to search",
-						Value:    "",
-						Category: "Fetching",
-						Action: func(ctx *cli.Context, s string) error {
-							if !strings.HasSuffix(s, "-county") {
-								return fmt.Errorf("county must end with '-county' (e.g. 'king-county')")
-							}
-							return nil
-						},
+					&cli.IntFlag{
+						Name:     "max-attempts",
+						Usage:    "Most times to request a page when the directory is unreachable, overloaded or rate limiting",
+						Value:    fetch.DefaultMaxAttempts,
+						Category: "Crawling",
 					},
-					&cli.StringFlag{
-						Name:     "insurance",
-						Usage:    "Insurance to search",
-						Value:    "premera",
-						Category: "Fetching",
+					&cli.DurationFlag{
+						Name:     "backoff",
+						Usage:    "Time to wait before retrying a page, doubling with each retry",
+						Value:    fetch.DefaultBackoff,
+						Category: "Crawling",
+					},
+					&cli.BoolFlag{
+						Name:     "resume",
+						Usage:    "Continue the last interrupted fetch, or the one of the search given with the location flags",
+						Category: "Crawling",
+					},
+					&cli.BoolFlag{
+						Name:     "resume-failed",
+						Usage:    "Fetch the pages that failed after every retry in earlier fetches again",
+						Category: "Crawling",
 					},
 					&cli.BoolFlag{
 						Name:     "view",
@@ -179,58 +199,108 @@ func main() {
 						Usage: "Port to run the GraphQL server on",
 						Value: "8080",
 					},
-				),
-				Before: func(c *cli.Context) error {
-					if _, err := os.Stat(c.String("config")); err != nil {
-						err := os.MkdirAll(c.String("config"), fs.ModePerm)
+				), reportFlags()...),
+				Before: openRepository,
+				Action: func(c *cli.Context) error {
+
+					source, selectors, err := selectedSource(c)
+					if err != nil {
+						return err
+					}
+
+					config := fetch.Config{
+						CacheDir:         filepath.Join(c.String("config"), "cache/"),
+						Source:           source,
+						Selectors:        selectors,
+						Parallelism:      c.Int("parallelism"),
+						Delay:            c.Duration("delay"),
+						RandomDelay:      c.Duration("random-delay"),
+						RespectRobotsTxt: c.Bool("respect-robots"),
+						MaxAttempts:      c.Int("max-attempts"),
+						Backoff:          c.Duration("backoff"),
+					}
+
+					if selectors != nil {
+						logger.DebugContext(c.Context, "using selector profile", slog.String("path", c.String("selectors")))
+					}
+
+					if c.Bool("resume-failed") {
+						return resumeFailed(c, logger, repo, config)
+					}
+
+					var crawl api.Crawl
+					if c.Bool("resume") {
+						crawl, err = resumeCrawl(c, logger, repo, &config)
+						if err != nil {
+							return err
+						}
+					} else {
+						config.URL, err = source.URL(query(c))
+						if err != nil {
+							return err
+						}
+
+						crawl, err = repo.CreateCrawl(c.Context, api.Crawl{Region: config.URL, Source: source.Name()})
 						if err != nil {
 							return err
 						}
 					}
+					config.Crawl = crawl.ID
 
-					repo = sqlite.NewRepository(c.String("db"), logger)
+					url := config.URL
+					// A resumed crawl counts as seeing everyone it saw
+					// before the interruption.
+					startedAt := crawl.StartedAt
 
-					err := repo.Init(context.Background())
+					run, err := startRun(c, repo, config)
 					if err != nil {
 						return err
 					}
 
-					err = repo.Migrate(context.Background())
-					if err != nil {
-						return err
+					var (
+						stats    fetch.Stats
+						stale    int
+						fetchErr error
+					)
+					defer func() {
+						finishRun(c.Context, logger, repo, run, stats, stale, fetchErr)
+					}()
+
+					logger.InfoContext(c.Context, "Fetching therapists", slog.String("source", config.Source.Name()))
+					s := fetch.NewFetcher(logger, repo)
+					stats, fetchErr = s.Fetch(c.Context, config)
+					logStats(c.Context, logger, stats)
+
+					if !fetch.Complete(fetchErr) {
+						logger.WarnContext(c.Context, "fetch incomplete, skipping stale check", slog.String("region", url))
+						return fetchErr
 					}
 
-					return nil
-				},
-				Action: func(c *cli.Context) error {
+					// An empty result usually means the page failed to load or
+					// its markup changed, so don't treat everyone as gone.
+					if stats.Saved == 0 {
+						logger.WarnContext(c.Context, "no therapists found, skipping stale check", slog.String("region", url))
+						return fetchErr
+					}
 
-					url, err := buildURL(c.String("state"), c.String("county"), c.String("city"), c.String("zip"))
+					stale, err = repo.MarkStale(c.Context, url, startedAt)
 					if err != nil {
 						return err
 					}
 
-					config := fetch.Config{URL: url, CacheDir: filepath.Join(c.String("config"), "cache/")}
-
-					logger.InfoContext(c.Context, "Fetching psychologytoday.com for therapists")
-					s := fetch.NewFetcher(c.Context, logger, repo)
-					therapists := s.Fetch(config)
+					logger.InfoContext(c.Context, "Marked therapists no longer listed as stale", slog.Int("count", stale))
 
-					uniqueTherapists := map[string]api.Therapist{}
-					for _, therapist := range therapists {
-						uniqueTherapists[therapist.Title] = therapist
+					diffs, err := runSavedSearches(c.Context, repo)
+					if err != nil {
+						return err
 					}
 
-					logger.InfoContext(c.Context, "Saving therapists to database")
-					for k, v := range uniqueTherapists {
-						logger.DebugContext(c.Context, "saving therapist", slog.String("title", k))
-						err := repo.Save(c.Context, v)
-						if err != nil {
-							return err
-						}
+					err = report(c, diffs)
+					if err != nil {
+						return err
 					}
 
-					logger.InfoContext(c.Context, "Saved therapists to database", slog.Int("count", len(uniqueTherapists)))
-					return nil
+					return fetchErr
 				},
 				After: func(c *cli.Context) error {
 					if c.Bool("view") {
@@ -254,52 +324,504 @@ func main() {
 						Aliases: []string{"w"},
 					},
 				},
-				Before: func(c *cli.Context) error {
-					if _, err := os.Stat(c.String("config")); err != nil {
-						err := os.MkdirAll(c.String("config"), fs.ModePerm)
-						if err != nil {
+				Before: openRepository,
+				Action: func(c *cli.Context) error {
+					if c.Bool("web") {
+						srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{
+							Repo: repo,
+						}}))
+
+						http.Handle("/", playground.ApolloSandboxHandler("GraphQL playground", "/query"))
+						http.Handle("/query", srv)
+
+						logger.InfoContext(c.Context, "connect to url for GraphQL playground", slog.String("url", "http://localhost:"+c.String("port")))
+						openBrowser(fmt.Sprintf("http://localhost:%s", c.String("port")))
+						return http.ListenAndServe(":"+c.String("port"), nil)
+					}
+
+					therapists, err := repo.List(c.Context)
+					if err != nil {
+						return err
+					}
+
+					if len(therapists) == 0 {
+						return errors.New("no therapists found - please run the scrape command first")
+					}
+
+					// The shortlist is created the first time a therapist is
+					// added to it.
+					return tui.Run(therapists, func(shortlist string, t api.Therapist) error {
+						_, err := repo.CreateShortlist(c.Context, shortlist)
+						if err != nil && !errors.Is(err, sqlite.ErrShortlistExists) {
 							return err
 						}
+
+						return repo.AddToShortlist(c.Context, shortlist, t.ID)
+					})
+				},
+			},
+			{
+				Name:      "search",
+				Usage:     "Full-text search therapist statements",
+				ArgsUsage: "<terms>",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Maximum number of results to show",
+						Value: 10,
+					},
+				},
+				Before: openRepository,
+				Action: func(c *cli.Context) error {
+					query := strings.Join(c.Args().Slice(), " ")
+					if strings.TrimSpace(query) == "" {
+						return errors.New("search terms are required (e.g. psych search \"anxiety emdr\")")
 					}
 
-					repo = sqlite.NewRepository(c.String("db"), logger)
+					results, err := repo.Search(c.Context, query, c.Int("limit"))
+					if err != nil {
+						return err
+					}
 
-					err := repo.Init(context.Background())
+					if len(results) == 0 {
+						logger.InfoContext(c.Context, "no therapists matched search", slog.String("query", query))
+						return nil
+					}
+
+					highlight := strings.NewReplacer(sqlite.SnippetStart, "\033[1m", sqlite.SnippetEnd, "\033[0m")
+					for _, r := range results {
+						fmt.Printf("%d\t%s - %s\n", r.Therapist.ID, r.Therapist.Title, r.Therapist.Credentials)
+						fmt.Printf("  %s\n", highlight.Replace(r.Snippet))
+						fmt.Printf("  %s\n\n", r.Therapist.Link)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "history",
+				Usage:     "Show how a therapist's details changed between fetches",
+				ArgsUsage: "<therapist id>",
+				Before:    openRepository,
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return errors.New("a therapist id is required (e.g. psych history 12)")
+					}
+
+					id, err := strconv.Atoi(c.Args().First())
+					if err != nil {
+						return fmt.Errorf("invalid therapist id %q", c.Args().First())
+					}
+
+					therapist, err := repo.Get(c.Context, id)
 					if err != nil {
 						return err
 					}
 
-					err = repo.Migrate(context.Background())
+					history, err := repo.History(c.Context, id)
 					if err != nil {
 						return err
 					}
 
+					printHistory(c.App.Writer, therapist, history)
 					return nil
 				},
+			},
+			{
+				Name:      "doctor",
+				Usage:     "Check which fields the scraper finds on a cached search results page",
+				ArgsUsage: "[url]",
+				Flags: append(sourceFlags(),
+					&cli.StringFlag{
+						Name:  "file",
+						Usage: "Check a search results page saved to this HTML file instead of the cache",
+					},
+				),
+				Action: doctor,
+			},
+			{
+				Name:  "export",
+				Usage: "Export therapists to a CSV, JSON, NDJSON or vCard file",
+				Flags: append(filterFlags(),
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "Export format: csv, json, ndjson or vcf. Defaults to the --out file extension, or json",
+					},
+					&cli.StringFlag{
+						Name:    "out",
+						Aliases: []string{"o"},
+						Usage:   "File to write to. Defaults to stdout",
+					},
+				),
+				Before: openRepository,
 				Action: func(c *cli.Context) error {
-					if c.Bool("web") {
-						srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{
-							Repo: repo,
-						}}))
+					format := export.JSON
+					switch {
+					case c.IsSet("format"):
+						f, err := export.ParseFormat(c.String("format"))
+						if err != nil {
+							return err
+						}
+						format = f
+					case c.IsSet("out"):
+						f, err := export.FormatFor(c.String("out"))
+						if err != nil {
+							return fmt.Errorf("%w, set one with --format", err)
+						}
+						format = f
+					}
 
-						http.Handle("/", playground.ApolloSandboxHandler("GraphQL playground", "/query"))
-						http.Handle("/query", srv)
+					params, err := filterParams(c)
+					if err != nil {
+						return err
+					}
 
-						logger.InfoContext(c.Context, "connect to url for GraphQL playground", slog.String("url", "http://localhost:"+c.String("port")))
-						openBrowser(fmt.Sprintf("http://localhost:%s", c.String("port")))
-						return http.ListenAndServe(":"+c.String("port"), nil)
+					therapists, err := repo.Find(c.Context, &params)
+					if err != nil {
+						return err
 					}
 
-					therapists, err := repo.List(c.Context)
+					if !c.IsSet("out") {
+						return export.Write(os.Stdout, format, therapists)
+					}
+
+					f, err := os.Create(c.String("out"))
 					if err != nil {
 						return err
 					}
+					defer f.Close()
 
-					if len(therapists) == 0 {
-						return errors.New("no therapists found - please run the scrape command first")
+					err = export.Write(f, format, therapists)
+					if err != nil {
+						return err
 					}
 
-					return tui.Run(therapists)
+					logger.InfoContext(c.Context, "Exported therapists", slog.Int("count", len(therapists)), slog.String("path", c.String("out")))
+					return f.Close()
+				},
+			},
+			{
+				Name:      "import",
+				Usage:     "Import therapists from a CSV or JSON file",
+				ArgsUsage: "<file>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "Import format: csv or json. Defaults to the file extension",
+					},
+					&cli.StringFlag{
+						Name:  "source",
+						Usage: "Source recorded on imported therapists. Defaults to the file name without its extension",
+					},
+					&cli.StringSliceFlag{
+						Name:  "map",
+						Usage: "Read a therapist field from a differently named column, as field=column (e.g. --map title=\"Provider Name\"). Repeatable",
+					},
+					&cli.StringFlag{
+						Name:  "mapping",
+						Usage: "JSON file mapping therapist fields to column names, e.g. {\"title\": \"Provider Name\"}",
+					},
+					&cli.StringFlag{
+						Name:  "separator",
+						Usage: "Separator between values in list columns such as specialties",
+						Value: ";",
+					},
+				},
+				Before: openRepository,
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return errors.New("a file to import is required (e.g. psych import --format csv directory.csv)")
+					}
+					path := c.Args().First()
+					name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+					format, err := importer.ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+					if c.IsSet("format") {
+						format, err = importer.ParseFormat(c.String("format"))
+					}
+					if err != nil {
+						return err
+					}
+
+					mapping := importer.Mapping{}
+					if c.IsSet("mapping") {
+						b, err := os.ReadFile(c.String("mapping"))
+						if err != nil {
+							return err
+						}
+
+						err = json.Unmarshal(b, &mapping)
+						if err != nil {
+							return fmt.Errorf("reading %s: %w", c.String("mapping"), err)
+						}
+					}
+
+					for _, m := range c.StringSlice("map") {
+						field, column, ok := strings.Cut(m, "=")
+						if !ok {
+							return fmt.Errorf("invalid --map %q, must be field=column", m)
+						}
+						mapping[strings.TrimSpace(field)] = column
+					}
+
+					source := name
+					if c.IsSet("source") {
+						source = c.String("source")
+					}
+
+					f, err := os.Open(path)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+
+					reader := importer.Reader{
+						Format:    format,
+						Mapping:   mapping,
+						Separator: c.String("separator"),
+						Source:    source,
+					}
+
+					therapists, err := reader.Read(f)
+					if err != nil {
+						return fmt.Errorf("reading %s: %w", path, err)
+					}
+
+					_, err = saveTherapists(c.Context, logger, repo, therapists, time.Now().UTC())
+					return err
+				},
+			},
+			{
+				Name:  "watch",
+				Usage: "Save searches and report how their matches change after each fetch",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Save a search under a name",
+						ArgsUsage: "<name>",
+						Flags:     filterFlags(),
+						Before:    openRepository,
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return errors.New("a saved search name is required (e.g. psych watch add --credentials lmft --accepting \"lmft accepting\")")
+							}
+
+							params, err := filterParams(c)
+							if err != nil {
+								return err
+							}
+
+							search, err := repo.CreateSavedSearch(c.Context, c.Args().First(), params)
+							if err != nil {
+								return err
+							}
+
+							logger.InfoContext(c.Context, "Saved search", slog.String("name", search.Name))
+							return nil
+						},
+					},
+					{
+						Name:   "list",
+						Usage:  "List saved searches",
+						Before: openRepository,
+						Action: func(c *cli.Context) error {
+							searches, err := repo.SavedSearches(c.Context)
+							if err != nil {
+								return err
+							}
+
+							for _, s := range searches {
+								params, err := json.Marshal(s.Params)
+								if err != nil {
+									return err
+								}
+
+								lastRun := "never"
+								if !s.LastRunAt.IsZero() {
+									lastRun = s.LastRunAt.Local().Format(time.DateTime)
+								}
+
+								fmt.Printf("%s\tlast run %s\n\t%s\n", s.Name, lastRun, params)
+							}
+
+							return nil
+						},
+					},
+					{
+						Name:      "run",
+						Usage:     "Report changes to saved searches since they were last run",
+						ArgsUsage: "[name]...",
+						Flags:     reportFlags(),
+						Before:    openRepository,
+						Action: func(c *cli.Context) error {
+							diffs, err := runSavedSearches(c.Context, repo, c.Args().Slice()...)
+							if err != nil {
+								return err
+							}
+
+							return report(c, diffs)
+						},
+					},
+				},
+			},
+			{
+				Name:  "runs",
+				Usage: "Show the history of fetches",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "List recent fetches and how they changed the therapists",
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Most fetches to list, or 0 for all",
+								Value: 20,
+							},
+						},
+						Before: openRepository,
+						Action: func(c *cli.Context) error {
+							runs, err := repo.CrawlRuns(c.Context, c.Int("limit"))
+							if err != nil {
+								return err
+							}
+
+							printRuns(c.App.Writer, runs)
+							return nil
+						},
+					},
+					{
+						Name:      "show",
+						Usage:     "Show the details and errors of a fetch",
+						ArgsUsage: "<run id>",
+						Before:    openRepository,
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return errors.New("a run id is required (e.g. psych runs show 3)")
+							}
+
+							id, err := strconv.Atoi(c.Args().First())
+							if err != nil {
+								return fmt.Errorf("invalid run id %q", c.Args().First())
+							}
+
+							run, err := repo.CrawlRun(c.Context, id)
+							if err != nil {
+								return err
+							}
+
+							printRun(c.App.Writer, run)
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "Manage shortlists of therapists",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "create",
+						Usage:     "Create a new shortlist",
+						ArgsUsage: "<name>",
+						Before:    openRepository,
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return errors.New("a shortlist name is required (e.g. psych list create favorites)")
+							}
+
+							shortlist, err := repo.CreateShortlist(c.Context, c.Args().First())
+							if err != nil {
+								return err
+							}
+
+							logger.InfoContext(c.Context, "Created shortlist", slog.String("name", shortlist.Name))
+							return nil
+						},
+					},
+					{
+						Name:      "add",
+						Usage:     "Add therapists to a shortlist",
+						ArgsUsage: "<name> <therapist id>...",
+						Before:    openRepository,
+						Action: func(c *cli.Context) error {
+							name, ids, err := shortlistArgs(c)
+							if err != nil {
+								return err
+							}
+
+							for _, id := range ids {
+								err := repo.AddToShortlist(c.Context, name, id)
+								if err != nil {
+									return fmt.Errorf("therapist %d: %w", id, err)
+								}
+							}
+
+							logger.InfoContext(c.Context, "Added therapists to shortlist", slog.String("name", name), slog.Int("count", len(ids)))
+							return nil
+						},
+					},
+					{
+						Name:      "remove",
+						Usage:     "Remove therapists from a shortlist",
+						ArgsUsage: "<name> <therapist id>...",
+						Before:    openRepository,
+						Action: func(c *cli.Context) error {
+							name, ids, err := shortlistArgs(c)
+							if err != nil {
+								return err
+							}
+
+							for _, id := range ids {
+								err := repo.RemoveFromShortlist(c.Context, name, id)
+								if err != nil {
+									return fmt.Errorf("therapist %d: %w", id, err)
+								}
+							}
+
+							logger.InfoContext(c.Context, "Removed therapists from shortlist", slog.String("name", name), slog.Int("count", len(ids)))
+							return nil
+						},
+					},
+					{
+						Name:      "show",
+						Usage:     "Show the therapists on a shortlist, or all shortlists if no name is given",
+						ArgsUsage: "[name]",
+						Before:    openRepository,
+						Action: func(c *cli.Context) error {
+							if c.NArg() == 0 {
+								shortlists, err := repo.Shortlists(c.Context)
+								if err != nil {
+									return err
+								}
+
+								for _, s := range shortlists {
+									fmt.Printf("%s (%d)\n", s.Name, s.Size)
+								}
+
+								return nil
+							}
+
+							shortlist, err := repo.Shortlist(c.Context, c.Args().First())
+							if err != nil {
+								return err
+							}
+
+							therapists, err := repo.Find(c.Context, &api.GetTherapistParams{Shortlist: &shortlist.Name})
+							if err != nil {
+								return err
+							}
+
+							for _, t := range therapists {
+								fmt.Printf("%d\t%s - %s\n", t.ID, t.Title, t.Credentials)
+								fmt.Printf("\t%s\n", t.Phone)
+								fmt.Printf("\t%s\n\n", t.Link)
+							}
+
+							return nil
+						},
+					},
 				},
 			},
 		}}
@@ -307,29 +829,67 @@ func main() {
 	sort.Sort(cli.FlagsByName(app.Flags))
 	sort.Sort(cli.CommandsByName(app.Commands))
 
-	if err := app.Run(os.Args); err != nil {
+	// Interrupting a fetch cancels it, and a second interrupt exits at once.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := app.RunContext(ctx, os.Args); err != nil {
 		logger.Error(err.Error())
 		os.
 			Exit(1)
 	}
 }
 
-func buildURL(state string, county string, city string, zip string) (string, error) {
-	base := fmt.Sprintf("https://www.psychologytoday.com/us/therapists/")
+// saveTherapists upserts therapists as seen at the given time, skipping any
+// without a profile link. It returns the number of therapists saved.
+func saveTherapists(ctx context.Context, logger *slog.Logger, repo therapy.Repository, therapists []api.Therapist, seenAt time.Time) (int, error) {
+	uniqueTherapists := map[string]bool{}
+	batch := make([]api.Therapist, 0, len(therapists))
+	for _, therapist := range therapists {
+		if therapist.Link == "" {
+			logger.WarnContext(ctx, "skipping therapist without profile link", slog.String("title", therapist.Title))
+			continue
+		}
+
+		therapist.LastSeenAt = seenAt
+		uniqueTherapists[therapist.Link] = true
+		batch = append(batch, therapist)
+	}
 
-	if zip != "" {
-		return url.JoinPath(base, zip)
+	logger.InfoContext(ctx, "Saving therapists to database")
+	result, err := repo.SaveBatch(ctx, batch)
+	if err != nil {
+		return 0, err
 	}
 
-	if state != "" && county != "" {
-		return url.JoinPath(base, state, county)
+	logger.InfoContext(ctx, "Saved therapists to database",
+		slog.Int("count", len(uniqueTherapists)),
+		slog.Int("new", result.New),
+		slog.Int("updated", result.Updated),
+	)
+	return len(uniqueTherapists), nil
+}
+
+// shortlistArgs parses the shortlist name and therapist IDs given to the list
+// add and remove commands.
+func shortlistArgs(c *cli.Context) (string, []int, error) {
+	if c.NArg() < 2 {
+		return "", nil, errors.New("a shortlist name and at least one therapist id are required")
 	}
 
-	if state != "" && city != "" {
-		return url.JoinPath(base, state, city)
+	ids := make([]int, 0, c.NArg()-1)
+	for _, arg := range c.Args().Tail() {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid therapist id %q", arg)
+		}
+		ids = append(ids, id)
 	}
 
-	return "", errors.New(ErrNotEnoughFlags)
+	return c.Args().First(), ids, nil
 }
 
 func openBrowser(url string) error {
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+// printRuns prints a table of crawl runs, one per line.
+func printRuns(out io.Writer, runs []api.CrawlRun) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTARTED\tDURATION\tNEW\tUPDATED\tUNCHANGED\tVANISHED\tERRORS\tREGION")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			run.ID,
+			run.StartedAt.Local().Format(time.DateTime),
+			runDuration(run),
+			run.New,
+			run.Updated,
+			run.Unchanged,
+			run.Vanished,
+			len(run.Errors),
+			run.Region,
+		)
+	}
+	w.Flush()
+}
+
+// printRun prints everything recorded about a crawl run.
+func printRun(out io.Writer, run api.CrawlRun) {
+	finished := "unfinished"
+	if !run.FinishedAt.IsZero() {
+		finished = run.FinishedAt.Local().Format(time.DateTime)
+	}
+
+	crawl := "none"
+	if run.CrawlID != 0 {
+		crawl = strconv.Itoa(run.CrawlID)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Run:\t%d\n", run.ID)
+	fmt.Fprintf(w, "Crawl:\t%s\n", crawl)
+	fmt.Fprintf(w, "Region:\t%s\n", run.Region)
+	fmt.Fprintf(w, "Source:\t%s\n", run.Source)
+	fmt.Fprintf(w, "Started:\t%s\n", run.StartedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Finished:\t%s\n", finished)
+	fmt.Fprintf(w, "Duration:\t%s\n", runDuration(run))
+	fmt.Fprintf(w, "Complete:\t%s\n", yesNo(run.Complete))
+	fmt.Fprintf(w, "Pages:\t%d fetched, %d failed\n", run.Pages, run.Failed)
+	fmt.Fprintf(w, "Therapists:\t%d new, %d updated, %d unchanged, %d vanished\n", run.New, run.Updated, run.Unchanged, run.Vanished)
+	w.Flush()
+
+	if len(run.Params) > 0 {
+		fmt.Fprintln(out, "\nFlags:")
+		for _, p := range run.Params {
+			fmt.Fprintf(out, "  --%s %s\n", p.Name, p.Value)
+		}
+	}
+
+	if len(run.Errors) > 0 {
+		fmt.Fprintf(out, "\nErrors (%d):\n", len(run.Errors))
+		for _, err := range run.Errors {
+			fmt.Fprintf(out, "  %s\n", err)
+		}
+	}
+}
+
+// runDuration is how long a run took, or "-" if it never finished.
+func runDuration(run api.CrawlRun) string {
+	if run.FinishedAt.IsZero() {
+		return "-"
+	}
+
+	return run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond).String()
+}
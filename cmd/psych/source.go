@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brittonhayes/therapy/fetch"
+	"github.com/brittonhayes/therapy/fetch/psychologytoday"
+	"github.com/urfave/cli/v2"
+)
+
+// sourceFlags are the flags choosing a source to fetch from and the area to
+// search it for.
+func sourceFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "source",
+			Usage:    fmt.Sprintf("Directory to fetch from (%s)", strings.Join(fetch.Sources(), ", ")),
+			Value:    psychologytoday.Name,
+			Category: "Fetching",
+		},
+		&cli.StringFlag{
+			Name:     "selectors",
+			Usage:    "YAML or JSON selector profile overriding the source's built-in CSS selectors",
+			Category: "Fetching",
+		},
+		&cli.StringFlag{
+			Name:     "state",
+			Usage:    "State to search",
+			Value:    "",
+			Category: "Fetching",
+		},
+		&cli.StringFlag{
+			Name:     "country",
+			Usage:    "Country to search",
+			Value:    "us",
+			Category: "Fetching",
+			// Temporarily hidden until supported
+			Hidden: true,
+			Action: func(ctx *cli.Context, s string) error {
+				if s != "us" && s != "ca" {
+					return errors.New("only us or ca are supported at this time")
+				}
+				return nil
+			},
+		},
+		&cli.StringFlag{
+			Name:     "city",
+			Usage:    "City to search",
+			Value:    "",
+			Category: "Fetching",
+		},
+		&cli.StringFlag{
+			Name:     "zip",
+			Usage:    "Zip code to search",
+			Value:    "",
+			Category: "Fetching",
+		},
+		&cli.StringFlag{
+			Name:     "county",
+			Usage:    "County to search",
+			Value:    "",
+			Category: "Fetching",
+			Action: func(ctx *cli.Context, s string) error {
+				if !strings.HasSuffix(s, "-county") {
+					return fmt.Errorf("county must end with '-county' (e.g. 'king-county')")
+				}
+				return nil
+			},
+		},
+		&cli.StringFlag{
+			Name:     "insurance",
+			Usage:    "Only fetch therapists accepting this insurance (e.g. 'premera')",
+			Value:    "",
+			Category: "Fetching",
+		},
+	}
+}
+
+// selectedSource returns the source chosen with --source, and the selector
+// profile given with --selectors merged over its defaults. The selectors are
+// nil when no profile was given.
+func selectedSource(c *cli.Context) (fetch.Source, *fetch.Selectors, error) {
+	source, err := fetch.Lookup(c.String("source"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !c.IsSet("selectors") {
+		return source, nil, nil
+	}
+
+	override, err := fetch.LoadSelectors(c.String("selectors"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selectors, err := source.Selectors().Merge(override)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return source, &selectors, nil
+}
+
+// query builds a source query from the location flags.
+func query(c *cli.Context) fetch.Query {
+	return fetch.Query{
+		Country:   c.String("country"),
+		State:     c.String("state"),
+		County:    c.String("county"),
+		City:      c.String("city"),
+		Zip:       c.String("zip"),
+		Insurance: c.String("insurance"),
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/brittonhayes/therapy"
+	"github.com/brittonhayes/therapy/api"
+	"github.com/urfave/cli/v2"
+)
+
+// reportFlags returns the flags that control where saved search changes are
+// reported in addition to stdout.
+func reportFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "report",
+			Usage:    "Also write saved search changes to this JSON file",
+			Category: "Watching",
+		},
+		&cli.StringFlag{
+			Name:     "webhook",
+			Usage:    "Also POST saved search changes as JSON to this URL (e.g. 'http://localhost:9000/hook')",
+			Category: "Watching",
+		},
+	}
+}
+
+// runSavedSearches runs the named saved searches, or all of them if no names
+// are given, and returns their changes.
+func runSavedSearches(ctx context.Context, repo therapy.Repository, names ...string) ([]api.SearchDiff, error) {
+	if len(names) == 0 {
+		searches, err := repo.SavedSearches(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range searches {
+			names = append(names, s.Name)
+		}
+	}
+
+	diffs := make([]api.SearchDiff, 0, len(names))
+	for _, name := range names {
+		diff, err := repo.RunSavedSearch(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("saved search %q: %w", name, err)
+		}
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// report prints saved search changes to stdout and sends them to the
+// destinations given by reportFlags.
+func report(c *cli.Context, diffs []api.SearchDiff) error {
+	for _, d := range diffs {
+		printDiff(d)
+	}
+
+	if len(diffs) == 0 || (!c.IsSet("report") && !c.IsSet("webhook")) {
+		return nil
+	}
+
+	body, err := json.MarshalIndent(diffs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if c.IsSet("report") {
+		err := os.WriteFile(c.String("report"), body, 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.IsSet("webhook") {
+		err := postWebhook(c.Context, c.String("webhook"), body)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printDiff(d api.SearchDiff) {
+	if d.Empty() {
+		fmt.Printf("%s: no changes\n", d.Search)
+		return
+	}
+
+	fmt.Printf("%s: %d new, %d no longer matching, %d changed\n", d.Search, len(d.Added), len(d.Removed), len(d.Changed))
+	for _, t := range d.Added {
+		fmt.Printf("  + %d\t%s - %s\t%s\n", t.ID, t.Title, t.Credentials, t.Link)
+	}
+	for _, t := range d.Removed {
+		fmt.Printf("  - %d\t%s - %s\t%s\n", t.ID, t.Title, t.Credentials, t.Link)
+	}
+	for _, ch := range d.Changed {
+		fmt.Printf("  ~ %d\t%s - accepting appointments: %s -> %s\n", ch.Therapist.ID, ch.Therapist.Title, yesNo(ch.Was), yesNo(ch.Therapist.AcceptingAppointments))
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func postWebhook(ctx context.Context, url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with %s", url, res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package export writes therapists out in formats other tools can read.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	VCard  Format = "vcf"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{CSV, JSON, NDJSON, VCard}
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, name)
+}
+
+// FormatFor guesses the format from a file name's extension.
+func FormatFor(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "vcard" {
+		ext = string(VCard)
+	}
+
+	return ParseFormat(ext)
+}
+
+// Write writes the therapists to w in the given format.
+func Write(w io.Writer, format Format, therapists []api.Therapist) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, therapists)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if therapists == nil {
+			therapists = []api.Therapist{}
+		}
+		return enc.Encode(therapists)
+	case NDJSON:
+		enc := json.NewEncoder(w)
+		for _, t := range therapists {
+			err := enc.Encode(t)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case VCard:
+		return writeVCards(w, therapists)
+	}
+
+	return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+// column is a CSV column.
+type column struct {
+	name  string
+	value func(t api.Therapist) string
+}
+
+var columns = []column{
+	{"id", func(t api.Therapist) string { return strconv.Itoa(t.ID) }},
+	{"title", func(t api.Therapist) string { return t.Title }},
+	{"credentials", func(t api.Therapist) string { return t.Credentials }},
+	{"accepting_appointments", func(t api.Therapist) string { return strconv.FormatBool(t.AcceptingAppointments) }},
+	{"verified", func(t api.Therapist) string { return t.Verified }},
+	{"phone", func(t api.Therapist) string { return t.Phone }},
+	{"location", func(t api.Therapist) string { return t.Location }},
+	{"link", func(t api.Therapist) string { return t.Link }},
+	{"statement", func(t api.Therapist) string { return t.Statement }},
+	{"fees", func(t api.Therapist) string { return t.Fees }},
+	{"in_person", func(t api.Therapist) string { return strconv.FormatBool(t.InPerson) }},
+	{"telehealth", func(t api.Therapist) string { return strconv.FormatBool(t.Telehealth) }},
+	{"insurance", func(t api.Therapist) string { return list(t.Insurance) }},
+	{"specialties", func(t api.Therapist) string { return list(t.Specialties) }},
+	{"issues", func(t api.Therapist) string { return list(t.Issues) }},
+	{"modalities", func(t api.Therapist) string { return list(t.Modalities) }},
+	{"age_groups", func(t api.Therapist) string { return list(t.AgeGroups) }},
+	{"languages", func(t api.Therapist) string { return list(t.Languages) }},
+	{"tags", func(t api.Therapist) string { return list(t.Tags) }},
+	{"rating", func(t api.Therapist) string { return optionalInt(t.Rating) }},
+	{"contact_status", func(t api.Therapist) string { return string(t.ContactStatus) }},
+	{"latitude", func(t api.Therapist) string { return optionalFloat(t.Latitude) }},
+	{"longitude", func(t api.Therapist) string { return optionalFloat(t.Longitude) }},
+	{"region", func(t api.Therapist) string { return t.Region }},
+	{"source", func(t api.Therapist) string { return t.Source }},
+	{"first_seen_at", func(t api.Therapist) string { return t.FirstSeenAt.Format(time.RFC3339) }},
+	{"last_seen_at", func(t api.Therapist) string { return t.LastSeenAt.Format(time.RFC3339) }},
+	{"stale", func(t api.Therapist) string { return strconv.FormatBool(t.Stale) }},
+}
+
+func writeCSV(w io.Writer, therapists []api.Therapist) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+
+	err := cw.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range therapists {
+		record := make([]string, len(columns))
+		for i, c := range columns {
+			record[i] = c.value(t)
+		}
+
+		err := cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// list joins list-valued attributes into a single CSV cell.
+func list(values []string) string {
+	return strings.Join(values, "; ")
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
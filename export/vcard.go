@@ -0,0 +1,107 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+// writeVCards writes each therapist as a vCard 3.0 contact so the file can be
+// imported into an address book.
+func writeVCards(w io.Writer, therapists []api.Therapist) error {
+	bw := bufio.NewWriter(w)
+
+	for _, t := range therapists {
+		family, given := splitName(t.Title)
+
+		lines := []string{
+			"BEGIN:VCARD",
+			"VERSION:3.0",
+			"FN:" + escape(t.Title),
+			"N:" + escape(family) + ";" + escape(given) + ";;;",
+		}
+
+		if t.Credentials != "" {
+			lines = append(lines, "TITLE:"+escape(t.Credentials))
+		}
+
+		if t.Phone != "" {
+			lines = append(lines, "TEL;TYPE=WORK,VOICE:"+escape(t.Phone))
+		}
+
+		if t.Location != "" {
+			lines = append(lines, "ADR;TYPE=WORK:;;"+escape(t.Location)+";;;;")
+		}
+
+		if t.Link != "" {
+			lines = append(lines, "URL:"+t.Link)
+		}
+
+		if len(t.Tags) > 0 {
+			tags := make([]string, len(t.Tags))
+			for i, tag := range t.Tags {
+				tags[i] = escape(tag)
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(tags, ","))
+		}
+
+		if t.Statement != "" {
+			lines = append(lines, "NOTE:"+escape(t.Statement))
+		}
+
+		lines = append(lines, "END:VCARD")
+
+		for _, line := range lines {
+			_, err := bw.WriteString(fold(line))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// splitName splits a display name into family and given names, treating the
+// last word as the family name.
+func splitName(name string) (family, given string) {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return "", ""
+	}
+
+	return words[len(words)-1], strings.Join(words[:len(words)-1], " ")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// fold splits a content line into lines of at most 75 octets, as vCard
+// requires, and terminates it with CRLF.
+func fold(line string) string {
+	const limit = 75
+
+	var b strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		width = limit - 1
+	}
+	fmt.Fprintf(&b, "%s\r\n", line)
+
+	return b.String()
+}
@@ -0,0 +1,195 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+var ErrNotCached = errors.New("page is not in the cache")
+
+// Page is a fetched page and the URL it was fetched from.
+type Page struct {
+	URL  string
+	Body []byte
+}
+
+// Report counts how often each selector field found something across a set
+// of pages.
+type Report struct {
+	// Pages is the number of pages checked.
+	Pages int
+	// Total is the number of search results on results pages, or the number
+	// of profile pages.
+	Total  int
+	Fields []FieldReport
+	// Profiles are the profile links parsed from the search results.
+	Profiles []string
+	// Next are the further search results pages linked from the pages.
+	Next []string
+}
+
+// FieldReport is how many of a report's results or profiles a field was
+// found in.
+type FieldReport struct {
+	Field    string
+	Selector Selector
+	Found    int
+}
+
+// Empty reports whether the field came back empty everywhere it was checked.
+func (f FieldReport) Empty() bool {
+	return f.Found == 0
+}
+
+// CachedPage reads a page from a fetch cache directory, as stored by the
+// crawler the last time it visited pageURL.
+func CachedPage(cacheDir, pageURL string) (Page, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return Page{}, err
+	}
+
+	f, err := os.Open(cachePath(cacheDir, u.String()))
+	if errors.Is(err, os.ErrNotExist) {
+		return Page{}, fmt.Errorf("%w: %s", ErrNotCached, pageURL)
+	}
+	if err != nil {
+		return Page{}, err
+	}
+	defer f.Close()
+
+	var resp colly.Response
+	err = gob.NewDecoder(f).Decode(&resp)
+	if err != nil {
+		return Page{}, fmt.Errorf("reading cached %s: %w", pageURL, err)
+	}
+
+	return Page{URL: u.String(), Body: resp.Body}, nil
+}
+
+// cachePath is where the crawler caches the page at pageURL, named after the
+// SHA-1 of its URL.
+func cachePath(cacheDir, pageURL string) string {
+	sum := sha1.Sum([]byte(pageURL))
+	hash := hex.EncodeToString(sum[:])
+
+	return filepath.Join(cacheDir, hash[:2], hash)
+}
+
+// CheckResults runs the source's search result parser over results pages
+// and reports which result fields came back empty.
+func CheckResults(source Source, sel Selectors, pages ...Page) (Report, error) {
+	report := Report{Pages: len(pages)}
+	counts := map[string]int{}
+	seen := map[string]bool{}
+
+	for _, page := range pages {
+		root, err := element(page)
+		if err != nil {
+			return report, err
+		}
+
+		root.DOM.Find(sel.Results).Each(func(i int, s *goquery.Selection) {
+			e := colly.NewHTMLElementFromSelectionNode(root.Response, s, s.Nodes[0], i)
+			report.Total++
+
+			for field := range sel.Result {
+				if sel.Result.Text(e, field) != "" {
+					counts[field]++
+				}
+			}
+
+			therapist := source.ParseResult(e, sel)
+			if therapist.Link != "" && !seen[therapist.Link] {
+				seen[therapist.Link] = true
+				report.Profiles = append(report.Profiles, therapist.Link)
+			}
+		})
+
+		for _, next := range source.Pages(root, sel) {
+			if !seen[next] {
+				seen[next] = true
+				report.Next = append(report.Next, next)
+			}
+		}
+	}
+
+	report.Fields = fieldReports(sel.Result, counts)
+	return report, nil
+}
+
+// CheckProfiles reports which profile fields came back empty across profile
+// pages.
+func CheckProfiles(sel Selectors, pages ...Page) (Report, error) {
+	report := Report{Pages: len(pages), Total: len(pages)}
+	counts := map[string]int{}
+
+	for _, page := range pages {
+		e, err := element(page)
+		if err != nil {
+			return report, err
+		}
+
+		for field := range sel.Profile {
+			if len(sel.Profile.Texts(e, field)) > 0 {
+				counts[field]++
+			}
+		}
+	}
+
+	report.Fields = fieldReports(sel.Profile, counts)
+	return report, nil
+}
+
+// element parses a page into the element the crawler would hand to an
+// "html" callback.
+func element(page Page) (*colly.HTMLElement, error) {
+	u, err := url.Parse(page.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page.Body))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", page.URL, err)
+	}
+
+	resp := &colly.Response{
+		StatusCode: 200,
+		Body:       page.Body,
+		Ctx:        colly.NewContext(),
+		Request:    &colly.Request{URL: u, Ctx: colly.NewContext()},
+	}
+
+	html := doc.Find("html")
+	if html.Length() == 0 {
+		return nil, fmt.Errorf("%s is not an html page", page.URL)
+	}
+
+	return colly.NewHTMLElementFromSelectionNode(resp, html, html.Nodes[0], 0), nil
+}
+
+// fieldReports lists the fields of set in sorted order with their counts.
+func fieldReports(set SelectorSet, counts map[string]int) []FieldReport {
+	fields := make([]FieldReport, 0, len(set))
+	for field, s := range set {
+		fields = append(fields, FieldReport{Field: field, Selector: s, Found: counts[field]})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Field < fields[j].Field
+	})
+
+	return fields
+}
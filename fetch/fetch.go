@@ -2,6 +2,12 @@ package fetch
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"log/slog"
 
@@ -12,93 +18,676 @@ import (
 )
 
 type Fetcher interface {
-	Fetch(config Config) []api.Therapist
+	// Fetch crawls the search results at config.URL and the profile of every
+	// therapist found, saving therapists to the repository in batches as
+	// they're parsed. Therapists parsed before the fetch is cancelled or
+	// fails are still saved. Errors from individual pages are joined
+	// together.
+	Fetch(ctx context.Context, config Config) (Stats, error)
 }
 
 type fetcher struct {
-	ctx    context.Context
 	repo   therapy.Repository
 	logger *slog.Logger
 }
 
+const (
+	DefaultBatchSize     = 50
+	DefaultFlushInterval = 5 * time.Second
+)
+
 type Config struct {
 	CacheDir string
 	URL      string
+	Source   Source
+	// Selectors overrides the source's default selector profile.
+	Selectors *Selectors
+	// Parallelism is the most requests made to a host at once. It defaults
+	// to one.
+	Parallelism int
+	// Delay is how long each request waits after the previous one to the
+	// same host, and RandomDelay is the most extra time added to it at
+	// random.
+	Delay       time.Duration
+	RandomDelay time.Duration
+	// RespectRobotsTxt skips pages the host's robots.txt disallows.
+	RespectRobotsTxt bool
+	// MaxAttempts is the most times a page is requested when the host is
+	// unreachable, overloaded or rate limiting. It defaults to
+	// DefaultMaxAttempts.
+	MaxAttempts int
+	// Backoff is how long to wait before the first retry of a page, doubling
+	// for each retry after it. A Retry-After header is honored instead when
+	// the host sends one. It defaults to DefaultBackoff.
+	Backoff time.Duration
+	// Failed are pages that failed in an earlier fetch of URL. When set,
+	// only they are fetched again rather than starting from URL, without
+	// following their pagination. Any page that succeeds is removed from the
+	// repository's failed pages.
+	Failed []api.FailedPage
+	// Crawl is the ID of the crawl to keep the queue of pages in, in the
+	// repository, so an interrupted fetch can be resumed. Pages the crawl
+	// already did aren't fetched again, and profiles it had yet to fetch
+	// are. When zero, the queue is kept in memory.
+	Crawl int
+	// BatchSize is the number of therapists saved to the repository at a
+	// time. It defaults to DefaultBatchSize.
+	BatchSize int
+	// FlushInterval is the longest a parsed therapist waits to be saved. It
+	// defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+}
+
+// Stats counts what a fetch did.
+type Stats struct {
+	// Pages is the number of pages fetched, including cached pages.
+	Pages int
+	// Retried is the number of times a page was requested again after it
+	// failed.
+	Retried int
+	// Failed is the number of pages that could not be fetched.
+	Failed int
+	// Blocked is the number of pages skipped because robots.txt disallows
+	// them.
+	Blocked int
+	// Saved is the number of distinct therapists saved to the repository.
+	Saved int
+	// New, Updated and Unchanged count how saving changed the therapists.
+	New       int
+	Updated   int
+	Unchanged int
+	Duration  time.Duration
+}
+
+// Throughput is the number of pages fetched per second.
+func (s Stats) Throughput() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+
+	return float64(s.Pages) / s.Duration.Seconds()
+}
+
+// URLError is a page that could not be fetched.
+type URLError struct {
+	URL string
+	// Profile is set when the page was a therapist's profile rather than a
+	// search results page.
+	Profile bool
+	Err     error
+}
+
+func (e *URLError) Error() string {
+	return e.URL + ": " + e.Err.Error()
+}
+
+func (e *URLError) Unwrap() error {
+	return e.Err
 }
 
-func NewFetcher(ctx context.Context, logger *slog.Logger, repo therapy.Repository) Fetcher {
+// Complete reports whether every search results page was read despite the
+// error returned by Fetch, so therapists missing from the results really are
+// no longer listed. Failed profile pages don't make a fetch incomplete.
+func Complete(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	for _, err := range errs {
+		var u *URLError
+		if !errors.As(err, &u) || !u.Profile {
+			return false
+		}
+	}
+
+	return true
+}
+
+func NewFetcher(logger *slog.Logger, repo therapy.Repository) Fetcher {
 	return &fetcher{
-		ctx:    ctx,
 		repo:   repo,
 		logger: logger,
 	}
 }
 
-func (s *fetcher) Fetch(config Config) []api.Therapist {
+func (s *fetcher) Fetch(ctx context.Context, config Config) (Stats, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	start := time.Now()
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if config.Backoff <= 0 {
+		config.Backoff = DefaultBackoff
+	}
+
+	var stats Stats
+	therapists := make(chan api.Therapist, config.BatchSize)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var result api.SaveResult
+		stats.Saved, result = s.save(ctx, cancel, config, therapists)
+		stats.New, stats.Updated, stats.Unchanged = result.New, result.Updated, result.Unchanged
+	}()
+
+	errs := s.crawl(ctx, config, therapists, &stats)
+	close(therapists)
+	<-done
+
+	stats.Duration = time.Since(start)
+
+	if config.Crawl != 0 && Complete(errors.Join(errs...)) && ctx.Err() == nil {
+		err := s.repo.FinishCrawl(context.WithoutCancel(ctx), config.Crawl)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// The cause is the failed save when saving stopped the crawl.
+	if ctx.Err() != nil {
+		s.logger.WarnContext(ctx, "fetch stopped early", slog.Int("saved", stats.Saved))
+		errs = append(errs, context.Cause(ctx))
+	}
+
+	return stats, errors.Join(errs...)
+}
+
+// crawl sends every therapist parsed from the search results and their
+// profiles to therapists, counting pages in stats, and returns the errors from
+// pages that failed. Search results pages are fetched from a queue and
+// profiles asynchronously, sharing one rate limit per host.
+func (s *fetcher) crawl(ctx context.Context, config Config, therapists chan<- api.Therapist, stats *Stats) []error {
+	source := config.Source
+
+	sel := source.Selectors()
+	if config.Selectors != nil {
+		sel = *config.Selectors
+	}
 
-	therapists := []api.Therapist{}
+	parallelism := config.Parallelism
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	// mu guards errs, warned and stats, which callbacks update concurrently.
+	var (
+		mu     sync.Mutex
+		errs   []error
+		warned = map[string]bool{}
+	)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+		if _, ok := err.(*URLError); ok {
+			stats.Failed++
+		}
+	}
 
 	c := colly.NewCollector(
-		colly.AllowedDomains("psychologytoday.com", "www.psychologytoday.com"),
+		colly.AllowedDomains(source.Domains()...),
 		colly.CacheDir(config.CacheDir),
-		colly.ParseHTTPErrorResponse(),
 	)
+	c.IgnoreRobotsTxt = !config.RespectRobotsTxt
+
+	// Requests in flight are cancelled along with ctx, and the rest are
+	// aborted before they're sent.
+	c.WithTransport(contextTransport{ctx: ctx, base: http.DefaultTransport})
 
-	q, err := queue.New(1, &queue.InMemoryQueueStorage{MaxSize: 10000})
+	err := c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: parallelism,
+		Delay:       config.Delay,
+		RandomDelay: config.RandomDelay,
+	})
 	if err != nil {
-		panic(err)
+		return []error{err}
 	}
 
-	q.AddURL(config.URL)
+	var storage queue.Storage = &queue.InMemoryQueueStorage{MaxSize: 10000}
+	if config.Crawl != 0 {
+		storage = &crawlQueue{ctx: context.WithoutCancel(ctx), repo: s.repo, crawl: config.Crawl}
+	}
 
-	c.OnHTML(".results-row", func(e *colly.HTMLElement) {
-		var therapist api.Therapist
+	q, err := queue.New(parallelism, storage)
+	if err != nil {
+		return []error{err}
+	}
 
-		s.logger.DebugContext(s.ctx, "scraping therapist", slog.String("name", e.ChildText(".results-row-name")))
+	// track records how far the crawl has got with a page.
+	track := func(page string, state api.PageState) {
+		if config.Crawl == 0 {
+			return
+		}
 
-		e.ForEach(".results-row-info", func(i int, e *colly.HTMLElement) {
-			therapist.Title = e.ChildText(".profile-title")
-			therapist.Credentials = e.ChildText(".profile-subtitle-credentials")
-			therapist.Verified = e.ChildText(".verified-badge .profile-subtitle-badge .not-small")
-			therapist.Statement = e.ChildText(".statements")
-			therapist.Link = e.ChildAttr("a", "href")
-		})
+		err := s.repo.SetCrawlPageState(context.WithoutCancel(ctx), config.Crawl, page, state)
+		if err != nil {
+			fail(fmt.Errorf("saving crawl page: %w", err))
+		}
+	}
 
-		e.ForEach(".profile-features", func(i int, e *colly.HTMLElement) {
-			therapist.AcceptingAppointments = e.ChildText(".accepting-appointments")
-		})
+	// emit sends the therapist whose profile was requested with rctx, once.
+	emit := func(rctx *colly.Context) {
+		therapist, ok := rctx.GetAny("therapist").(*api.Therapist)
+		if !ok || rctx.GetAny("sent") != nil {
+			return
+		}
 
-		e.ForEach(".results-row-contact", func(i int, e *colly.HTMLElement) {
-			therapist.Phone = e.ChildText(".results-row-mob")
-		})
+		rctx.Put("sent", true)
+		therapists <- *therapist
+	}
+
+	// Search results only carry a summary of each therapist, so every
+	// result's profile page is visited to fill in the remaining details
+	// before the therapist is sent on.
+	profiles := c.Clone()
+	profiles.Async = true
+
+	profiles.OnHTML("body", func(e *colly.HTMLElement) {
+		therapist, ok := e.Request.Ctx.GetAny("therapist").(*api.Therapist)
+		if !ok {
+			return
+		}
+
+		s.logger.DebugContext(ctx, "scraping therapist profile", slog.String("url", e.Request.URL.String()))
+		mu.Lock()
+		s.checkSelectors(ctx, e, warned, "profile", sel.Profile, "")
+		mu.Unlock()
+		source.ParseProfile(e, sel, therapist)
+	})
 
-		therapists = append(therapists, therapist)
+	profiles.OnScraped(func(r *colly.Response) {
+		emit(r.Ctx)
 	})
 
-	c.OnHTML(".pagination", func(e *colly.HTMLElement) {
-		hrefs := e.ChildAttrs("a[href].button-element.page-btn", "href")
-		for _, r := range hrefs {
-			q.AddURL(r)
+	c.OnHTML(sel.Results, func(e *colly.HTMLElement) {
+		therapist := source.ParseResult(e, sel)
+		therapist.Source = source.Name()
+		// Therapists are recorded under the search they were found by, so
+		// the ones missing from a later fetch of it can be marked stale.
+		therapist.Region = config.URL
+
+		s.logger.DebugContext(ctx, "scraping therapist", slog.String("name", therapist.Title))
+
+		if therapist.Link == "" {
+			therapists <- therapist
+			return
+		}
+
+		// The profile is queued even when the fetch was cancelled, so
+		// resuming the crawl fetches it.
+		if config.Crawl != 0 {
+			err := s.repo.QueueCrawlPage(context.WithoutCancel(ctx), api.CrawlPage{
+				CrawlID: config.Crawl,
+				URL:     therapist.Link,
+				Profile: true,
+			})
+			if err != nil {
+				fail(fmt.Errorf("saving crawl page: %w", err))
+			}
+		}
+
+		if ctx.Err() != nil {
+			therapists <- therapist
+			return
+		}
+
+		rctx := colly.NewContext()
+		rctx.Put("therapist", &therapist)
+		err := profiles.Request("GET", therapist.Link, nil, rctx, nil)
+		// A therapist listed twice was already sent with their profile.
+		if errors.Is(err, colly.ErrAlreadyVisited) {
+			return
+		}
+		if errors.Is(err, colly.ErrRobotsTxtBlocked) {
+			mu.Lock()
+			stats.Blocked++
+			mu.Unlock()
+		}
+		if err != nil {
+			s.logger.DebugContext(ctx, "failed to visit profile", slog.String("url", therapist.Link), slog.String("error", err.Error()))
+			track(therapist.Link, api.PageFailed)
+			emit(rctx)
+		}
+	})
+
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		if e.DOM.Find(sel.Results).Length() > 0 {
+			mu.Lock()
+			s.checkSelectors(ctx, e, warned, "result", sel.Result, sel.Results)
+			mu.Unlock()
+		}
+
+		// Only the failed pages themselves are fetched again, not the
+		// pages they link to.
+		if len(config.Failed) > 0 {
+			return
+		}
+
+		for _, page := range source.Pages(e, sel) {
+			err := q.AddURL(page)
+			if err != nil {
+				fail(&URLError{URL: page, Err: err})
+			}
 		}
 	})
 
 	c.OnRequest(func(r *colly.Request) {
-		s.logger.DebugContext(s.ctx, "requesting url", slog.String("url", r.URL.String()))
+		if ctx.Err() != nil {
+			r.Abort()
+			return
+		}
+
+		s.logger.DebugContext(ctx, "requesting url", slog.String("url", r.URL.String()))
 	})
 
+	// A therapist whose profile is never fetched is still sent on with the
+	// details from the search results.
+	profiles.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+			emit(r.Ctx)
+		}
+	})
+
+	fetched := func(r *colly.Response) {
+		mu.Lock()
+		stats.Pages++
+		mu.Unlock()
+	}
+
+	c.OnResponse(fetched)
+	profiles.OnResponse(fetched)
+
+	// Pages that failed before are forgotten once they succeed, whether
+	// they're fetched again on their own or by a later fetch.
+	succeeded := func(r *colly.Response) {
+		track(r.Request.URL.String(), api.PageDone)
+
+		err := s.repo.DeleteFailedPage(context.WithoutCancel(ctx), r.Request.URL.String())
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	c.OnScraped(succeeded)
+	profiles.OnScraped(succeeded)
+
+	// retry requests a failed page again after backing off, if the failure
+	// may be temporary and the page has attempts left. It reports whether
+	// the page was retried.
+	retry := func(r *colly.Response) bool {
+		attempts, _ := r.Ctx.GetAny("attempts").(int)
+		attempts++
+		r.Ctx.Put("attempts", attempts)
+
+		if !retryable(r) || attempts >= config.MaxAttempts {
+			return false
+		}
+
+		wait := backoff(r, config.Backoff, attempts)
+		s.logger.WarnContext(ctx, "retrying page",
+			slog.String("url", r.Request.URL.String()),
+			slog.Int("status", r.StatusCode),
+			slog.Int("attempt", attempts+1),
+			slog.Duration("wait", wait),
+		)
+		if !sleep(ctx, wait) {
+			return false
+		}
+
+		mu.Lock()
+		stats.Retried++
+		mu.Unlock()
+
+		// Search results pages are retried synchronously, so the error of
+		// the retry was already handled by the time it's returned here.
+		err := r.Request.Retry()
+		if err != nil {
+			s.logger.DebugContext(ctx, "retried page failed", slog.String("url", r.Request.URL.String()), slog.String("error", err.Error()))
+		}
+
+		return true
+	}
+
+	// failure handles a page that failed to fetch. Pages are retried while
+	// they have attempts left, and ones that still fail after every attempt
+	// are saved to the repository's failed pages to be fetched again later.
+	failure := func(r *colly.Response, err error, profile bool) {
+		if config.CacheDir != "" {
+			// Error responses are cached too, which would make every retry
+			// fail the same way.
+			os.Remove(cachePath(config.CacheDir, r.Request.URL.String()))
+		}
+
+		if ctx.Err() != nil || retry(r) {
+			return
+		}
+
+		// The fetch may have been cancelled while backing off.
+		if ctx.Err() != nil {
+			return
+		}
+
+		if profile {
+			emit(r.Ctx)
+			s.logger.ErrorContext(ctx, "fetcher encountered error on profile", slog.String("error", err.Error()))
+		} else {
+			s.logger.ErrorContext(ctx, "fetcher encountered error", slog.String("error", err.Error()))
+		}
+		s.logger.DebugContext(ctx, "error at url", slog.String("url", r.Request.URL.String()))
+		fail(&URLError{URL: r.Request.URL.String(), Profile: profile, Err: err})
+		track(r.Request.URL.String(), api.PageFailed)
+
+		if !retryable(r) {
+			return
+		}
+
+		attempts, _ := r.Ctx.GetAny("attempts").(int)
+		err = s.repo.SaveFailedPage(context.WithoutCancel(ctx), api.FailedPage{
+			URL:      r.Request.URL.String(),
+			Region:   config.URL,
+			Source:   source.Name(),
+			Profile:  profile,
+			Error:    err.Error(),
+			Attempts: attempts,
+		})
+		if err != nil {
+			fail(fmt.Errorf("saving failed page: %w", err))
+		}
+	}
+
 	c.OnError(func(r *colly.Response, err error) {
-		s.logger.ErrorContext(s.ctx, "fetcher encountered error", err)
-		s.logger.DebugContext(s.ctx, "error at url", slog.String("url", r.Request.URL.String()))
+		failure(r, err, false)
 	})
 
+	profiles.OnError(func(r *colly.Response, err error) {
+		failure(r, err, true)
+		if ctx.Err() != nil {
+			emit(r.Ctx)
+		}
+	})
+
+	// A resumed crawl only queues the first page again if it wasn't done.
+	if len(config.Failed) == 0 {
+		err = q.AddURL(config.URL)
+		if err != nil {
+			return []error{err}
+		}
+	}
+
+	if config.Crawl != 0 {
+		pending, err := s.repo.PendingCrawlPages(ctx, config.Crawl, true, 0, 0)
+		if err != nil {
+			return []error{err}
+		}
+
+		for _, page := range pending {
+			err := s.refetchProfile(ctx, profiles, page.URL)
+			if err != nil {
+				fail(&URLError{URL: page.URL, Profile: true, Err: err})
+				track(page.URL, api.PageFailed)
+			}
+		}
+	}
+
+	for _, page := range config.Failed {
+		if !page.Profile {
+			err := q.AddURL(page.URL)
+			if err != nil {
+				fail(&URLError{URL: page.URL, Err: err})
+			}
+			continue
+		}
+
+		err := s.refetchProfile(ctx, profiles, page.URL)
+		if err != nil {
+			fail(&URLError{URL: page.URL, Profile: true, Err: err})
+		}
+	}
+
 	err = q.Run(c)
 	if err != nil {
-		panic(err)
+		fail(err)
+	}
+	profiles.Wait()
+
+	if ctx.Err() == nil && q.IsEmpty() {
+		s.logger.DebugContext(ctx, "no more pages to scrape")
 	}
 
-	if q.IsEmpty() {
-		s.logger.DebugContext(s.ctx, "no more pages to scrape")
+	return errs
+}
+
+// refetchProfile requests a profile page that failed or was interrupted
+// before, to fill in the details of the therapist already saved with it.
+func (s *fetcher) refetchProfile(ctx context.Context, profiles *colly.Collector, link string) error {
+	found, err := s.repo.Find(ctx, &api.GetTherapistParams{Link: &link})
+	if err != nil {
+		return err
 	}
 
-	return therapists
+	if len(found) == 0 {
+		return fmt.Errorf("no therapist has this profile link")
+	}
+
+	therapist := found[0]
+	// The therapist is seen again now.
+	therapist.LastSeenAt = time.Time{}
+
+	rctx := colly.NewContext()
+	rctx.Put("therapist", &therapist)
+	return profiles.Request("GET", link, nil, rctx, nil)
+}
+
+// save saves the therapists it receives in batches until the channel is
+// closed, and returns the number of distinct therapists saved and how saving
+// changed them. Batches are still saved once ctx is cancelled, so an
+// interrupted fetch keeps what it parsed. A failed save stops the fetch by
+// cancelling ctx with the error.
+func (s *fetcher) save(ctx context.Context, cancel context.CancelCauseFunc, config Config, therapists <-chan api.Therapist) (int, api.SaveResult) {
+	ticker := time.NewTicker(config.FlushInterval)
+	defer ticker.Stop()
+
+	saveCtx := context.WithoutCancel(ctx)
+	batch := make([]api.Therapist, 0, config.BatchSize)
+	saved := map[string]bool{}
+	var total api.SaveResult
+	failed := false
+
+	flush := func() {
+		if len(batch) == 0 || failed {
+			batch = batch[:0]
+			return
+		}
+
+		result, err := s.repo.SaveBatch(saveCtx, batch)
+		if err != nil {
+			failed = true
+			cancel(fmt.Errorf("saving therapists: %w", err))
+		} else {
+			total.New += result.New
+			total.Updated += result.Updated
+			total.Unchanged += result.Unchanged
+			for _, t := range batch {
+				saved[t.Link] = true
+			}
+			s.logger.DebugContext(ctx, "saved therapists", slog.Int("count", len(batch)))
+		}
+
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case therapist, ok := <-therapists:
+			if !ok {
+				flush()
+				return len(saved), total
+			}
+
+			if therapist.Link == "" {
+				s.logger.WarnContext(ctx, "skipping therapist without profile link", slog.String("title", therapist.Title))
+				continue
+			}
+
+			batch = append(batch, therapist)
+			if len(batch) >= config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// contextTransport sends requests with a context, so they're cancelled with
+// it.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(r.WithContext(t.ctx))
+}
+
+// checkSelectors warns about required selectors that match nothing on a page
+// with results, which usually means the site's markup has changed. Each
+// field is only warned about once per fetch.
+func (s *fetcher) checkSelectors(ctx context.Context, e *colly.HTMLElement, warned map[string]bool, group string, set SelectorSet, scope string) {
+	for _, field := range set.missing(e, scope) {
+		if warned[group+"."+field] {
+			continue
+		}
+		warned[group+"."+field] = true
+
+		s.logger.WarnContext(ctx, "required selector matched nothing, the site's markup may have changed",
+			slog.String("field", group+"."+field),
+			slog.String("selector", set[field].CSS),
+			slog.String("url", e.Request.URL.String()),
+		)
+	}
 }
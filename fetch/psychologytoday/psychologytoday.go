@@ -0,0 +1,175 @@
+// Package psychologytoday crawls the psychologytoday.com therapist directory.
+package psychologytoday
+
+import (
+	_ "embed"
+	"net/url"
+	"strings"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/brittonhayes/therapy/fetch"
+	"github.com/gocolly/colly/v2"
+)
+
+// Name is the name the source is registered under.
+const Name = "psychologytoday"
+
+//go:embed selectors.yaml
+var defaultSelectors []byte
+
+func init() {
+	fetch.Register(source{})
+}
+
+type source struct{}
+
+func (source) Name() string { return Name }
+
+func (source) Domains() []string {
+	return []string{"psychologytoday.com", "www.psychologytoday.com"}
+}
+
+func (source) URL(q fetch.Query) (string, error) {
+	country := q.Country
+	if country == "" {
+		country = "us"
+	}
+
+	base := "https://www.psychologytoday.com/" + country + "/therapists/"
+
+	var (
+		path string
+		err  error
+	)
+
+	switch {
+	case q.Zip != "":
+		path, err = url.JoinPath(base, q.Zip)
+	case q.State != "" && q.County != "":
+		path, err = url.JoinPath(base, q.State, q.County)
+	case q.State != "" && q.City != "":
+		path, err = url.JoinPath(base, q.State, q.City)
+	default:
+		return "", fetch.ErrIncompleteQuery
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if q.Insurance == "" {
+		return path, nil
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set("category", strings.ToLower(strings.ReplaceAll(strings.TrimSpace(q.Insurance), " ", "-")))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func (source) Selectors() fetch.Selectors {
+	sel, err := fetch.ParseSelectors(defaultSelectors)
+	if err != nil {
+		panic("psychologytoday: invalid embedded selectors: " + err.Error())
+	}
+
+	return sel
+}
+
+func (source) ParseResult(e *colly.HTMLElement, sel fetch.Selectors) api.Therapist {
+	therapist := api.Therapist{
+		Title:                 sel.Result.Text(e, "title"),
+		Credentials:           sel.Result.Text(e, "credentials"),
+		Verified:              sel.Result.Text(e, "verified"),
+		Statement:             sel.Result.Text(e, "statement"),
+		AcceptingAppointments: acceptingAppointments(sel.Result.Text(e, "accepting_appointments")),
+		Phone:                 sel.Result.Text(e, "phone"),
+	}
+
+	if link := sel.Result.Text(e, "link"); link != "" {
+		therapist.Link = profileLink(e.Request.AbsoluteURL(link))
+	}
+
+	return therapist
+}
+
+func (source) Pages(e *colly.HTMLElement, sel fetch.Selectors) []string {
+	if sel.Pagination.CSS == "" {
+		return nil
+	}
+
+	var pages []string
+	for _, href := range e.ChildAttrs(sel.Pagination.CSS, sel.Pagination.Attr) {
+		pages = append(pages, e.Request.AbsoluteURL(href))
+	}
+
+	return pages
+}
+
+// ParseProfile fills in the details that are only available on a therapist's
+// profile page. Fields missing from the page are left as they were scraped
+// from the search results.
+func (source) ParseProfile(e *colly.HTMLElement, sel fetch.Selectors, therapist *api.Therapist) {
+	if statement := strings.Join(sel.Profile.Texts(e, "statement"), "\n\n"); statement != "" {
+		therapist.Statement = statement
+	}
+
+	if address := strings.Join(sel.Profile.Texts(e, "address"), ", "); address != "" {
+		therapist.Location = address
+	}
+
+	if fees := strings.Join(sel.Profile.Texts(e, "fees"), "; "); fees != "" {
+		therapist.Fees = fees
+	}
+
+	therapist.Insurance = sel.Profile.Texts(e, "insurance")
+	therapist.Specialties = sel.Profile.Texts(e, "specialties")
+	therapist.Issues = sel.Profile.Texts(e, "issues")
+	therapist.Modalities = sel.Profile.Texts(e, "modalities")
+	therapist.AgeGroups = sel.Profile.Texts(e, "age_groups")
+	therapist.Languages = sel.Profile.Texts(e, "languages")
+
+	therapist.Profiled = true
+	for _, format := range sel.Profile.Texts(e, "session_formats") {
+		format = strings.ToLower(format)
+		if strings.Contains(format, "person") {
+			therapist.InPerson = true
+		}
+		if strings.Contains(format, "online") || strings.Contains(format, "tele") || strings.Contains(format, "video") {
+			therapist.Telehealth = true
+		}
+	}
+}
+
+// profileLink strips tracking parameters and fragments from a profile URL so
+// the same therapist always resolves to the same link across fetches.
+func profileLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return u.String()
+}
+
+// acceptingAppointments interprets the free text appointment badge shown on
+// search results, such as "Accepting new clients" or "Waitlist for new clients".
+func acceptingAppointments(text string) bool {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "not accepting"), strings.Contains(text, "waitlist"):
+		return false
+	case strings.Contains(text, "accepting"):
+		return true
+	default:
+		return false
+	}
+}
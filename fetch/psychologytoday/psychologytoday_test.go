@@ -0,0 +1,658 @@
+package psychologytoday
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/brittonhayes/therapy"
+	"github.com/brittonhayes/therapy/api"
+	"github.com/brittonhayes/therapy/fetch"
+	"github.com/brittonhayes/therapy/sqlite"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+const origin = "https://www.psychologytoday.com"
+
+// local is the source pointed at a test server instead of the real site.
+type local struct {
+	source
+	host string
+}
+
+func (l local) Domains() []string { return []string{l.host} }
+
+// site serves the recorded pages in testdata.
+type site struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	unavailable map[string]int
+	served      map[string]int
+	edits       map[string]*strings.Replacer
+	// onServe is called with the name of each page before it's served.
+	onServe func(name string)
+}
+
+// fail makes the site answer requests for the named page with a 503 the next
+// n times.
+func (s *site) fail(name string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unavailable[name] = n
+}
+
+// edit makes the site serve the named page with old replaced by new.
+func (s *site) edit(name, old, new string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.edits[name] = strings.NewReplacer(old, new)
+}
+
+// fixtures serves the recorded pages in testdata the way the site lays them
+// out. Missing pages are answered with a 404.
+func fixtures(t *testing.T, missing ...string) *site {
+	t.Helper()
+
+	s := &site{unavailable: map[string]int{}, served: map[string]int{}, edits: map[string]*strings.Replacer{}}
+
+	serve := func(w http.ResponseWriter, name string) {
+		for _, m := range missing {
+			if m == name {
+				http.NotFound(w, nil)
+				return
+			}
+		}
+
+		s.mu.Lock()
+		n := s.unavailable[name]
+		if n > 0 {
+			s.unavailable[name] = n - 1
+		}
+		s.served[name]++
+		onServe := s.onServe
+		edit := s.edits[name]
+		s.mu.Unlock()
+
+		if onServe != nil {
+			onServe(name)
+		}
+
+		if n > 0 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			http.NotFound(w, nil)
+			return
+		}
+
+		if edit != nil {
+			b = []byte(edit.Replace(string(b)))
+		}
+
+		if filepath.Ext(name) == ".html" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		w.Write(b)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, "robots.txt")
+	})
+	mux.HandleFunc("/us/therapists/98027", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+		serve(w, "results-"+page+".html")
+	})
+	mux.HandleFunc("/us/therapists/", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, "profile-"+filepath.Base(r.URL.Path)+".html")
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// repository records the therapists and failed pages a fetch saves.
+type repository struct {
+	therapy.Repository
+
+	mu         sync.Mutex
+	therapists []api.Therapist
+	batches    int
+	failed     []api.FailedPage
+}
+
+func (r *repository) SaveBatch(ctx context.Context, therapists []api.Therapist) (api.SaveResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.therapists = append(r.therapists, therapists...)
+	r.batches++
+	return api.SaveResult{New: len(therapists)}, nil
+}
+
+func (r *repository) Find(ctx context.Context, params *api.GetTherapistParams) ([]api.Therapist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []api.Therapist
+	for _, t := range r.therapists {
+		if params.Link == nil || t.Link == *params.Link {
+			found = append(found, t)
+		}
+	}
+
+	return found, nil
+}
+
+func (r *repository) SaveFailedPage(ctx context.Context, page api.FailedPage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = append(r.failed, page)
+	return nil
+}
+
+func (r *repository) DeleteFailedPage(ctx context.Context, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, page := range r.failed {
+		if page.URL == url {
+			r.failed = append(r.failed[:i], r.failed[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// fetchFixtures fetches the recorded search for 98027 from srv.
+func fetchFixtures(t *testing.T, ctx context.Context, srv *site, config fetch.Config) (*repository, fetch.Stats, error) {
+	t.Helper()
+	repo := &repository{}
+	stats, err := fetchInto(t, ctx, repo, srv, config)
+	return repo, stats, err
+}
+
+// fetchInto fetches the recorded search for 98027 from srv into repo.
+func fetchInto(t *testing.T, ctx context.Context, repo therapy.Repository, srv *site, config fetch.Config) (fetch.Stats, error) {
+	t.Helper()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.URL = srv.URL + "/us/therapists/98027"
+	config.Source = local{host: u.Hostname()}
+
+	f := fetch.NewFetcher(slog.New(slog.NewTextHandler(io.Discard, nil)), repo)
+	return f.Fetch(ctx, config)
+}
+
+func TestFetch(t *testing.T) {
+	for _, parallelism := range []int{1, 3} {
+		t.Run(fmt.Sprintf("parallelism %d", parallelism), func(t *testing.T) {
+			testFetch(t, fetch.Config{Parallelism: parallelism})
+		})
+	}
+}
+
+func testFetch(t *testing.T, config fetch.Config) {
+	srv := fixtures(t)
+
+	repo, stats, err := fetchFixtures(t, context.Background(), srv, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Saved != 3 {
+		t.Errorf("saved %d therapists, want 3", stats.Saved)
+	}
+
+	if stats.Pages != 5 {
+		t.Errorf("fetched %d pages, want 5", stats.Pages)
+	}
+
+	// Links are recorded against the site rather than the test server so
+	// the golden file doesn't depend on its port.
+	therapists := repo.therapists
+	for i := range therapists {
+		therapists[i].Link = strings.Replace(therapists[i].Link, srv.URL, origin, 1)
+		therapists[i].Region = strings.Replace(therapists[i].Region, srv.URL, origin, 1)
+	}
+
+	sort.Slice(therapists, func(i, j int) bool {
+		return therapists[i].Link < therapists[j].Link
+	})
+
+	golden(t, "therapists.golden.json", therapists)
+}
+
+func TestFetchBatches(t *testing.T) {
+	repo, _, err := fetchFixtures(t, context.Background(), fixtures(t), fetch.Config{BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.batches != 2 || len(repo.therapists) != 3 {
+		t.Errorf("saved %d therapists in %d batches, want 3 in 2", len(repo.therapists), repo.batches)
+	}
+}
+
+func TestFetchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := fetchFixtures(t, ctx, fixtures(t), fetch.Config{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	if fetch.Complete(err) {
+		t.Error("cancelled fetch reported as complete")
+	}
+}
+
+func TestFetchProfileError(t *testing.T) {
+	repo, stats, err := fetchFixtures(t, context.Background(), fixtures(t, "profile-333333.html"), fetch.Config{})
+
+	var urlErr *fetch.URLError
+	if !errors.As(err, &urlErr) || !urlErr.Profile {
+		t.Fatalf("got error %v, want a profile URLError", err)
+	}
+
+	if !fetch.Complete(err) {
+		t.Error("fetch with a missing profile reported as incomplete")
+	}
+
+	if stats.Failed != 1 {
+		t.Errorf("%d pages failed, want 1", stats.Failed)
+	}
+
+	// Alex Kim is still saved with the details from the search results.
+	if len(repo.therapists) != 3 {
+		t.Errorf("saved %d therapists, want 3", len(repo.therapists))
+	}
+}
+
+func TestFetchRobotsTxt(t *testing.T) {
+	srv := fixtures(t)
+
+	repo, stats, err := fetchFixtures(t, context.Background(), srv, fetch.Config{RespectRobotsTxt: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// robots.txt disallows Sam Lee's profile, so he's saved from the search
+	// results alone.
+	if stats.Blocked != 1 {
+		t.Errorf("%d pages blocked, want 1", stats.Blocked)
+	}
+
+	for _, therapist := range repo.therapists {
+		if therapist.Title == "Sam Lee" && therapist.Specialties != nil {
+			t.Error("profile disallowed by robots.txt was fetched")
+		}
+	}
+}
+
+func TestFetchRetry(t *testing.T) {
+	srv := fixtures(t)
+	srv.fail("results-2.html", 1)
+	srv.fail("profile-222222.html", 2)
+
+	repo, stats, err := fetchFixtures(t, context.Background(), srv, fetch.Config{MaxAttempts: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Retried != 3 {
+		t.Errorf("retried %d times, want 3", stats.Retried)
+	}
+
+	if len(repo.therapists) != 3 || len(repo.failed) != 0 {
+		t.Errorf("saved %d therapists and %d failed pages, want 3 and 0", len(repo.therapists), len(repo.failed))
+	}
+}
+
+func TestFetchFailedPages(t *testing.T) {
+	srv := fixtures(t)
+	srv.fail("profile-222222.html", 2)
+
+	repo, _, err := fetchFixtures(t, context.Background(), srv, fetch.Config{MaxAttempts: 2})
+	if !fetch.Complete(err) || err == nil {
+		t.Fatalf("got error %v, want a profile URLError", err)
+	}
+
+	if len(repo.failed) != 1 || repo.failed[0].Attempts != 2 || !repo.failed[0].Profile {
+		t.Fatalf("got failed pages %+v, want Sam Lee's profile after 2 attempts", repo.failed)
+	}
+
+	stats, err := fetchInto(t, context.Background(), repo, srv, fetch.Config{Failed: repo.failed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Pages != 1 {
+		t.Errorf("fetched %d pages, want only the failed profile", stats.Pages)
+	}
+
+	if len(repo.failed) != 0 {
+		t.Errorf("profile is still failed after it was fetched again")
+	}
+
+	found, _ := repo.Find(context.Background(), &api.GetTherapistParams{})
+	last := found[len(found)-1]
+	if last.Title != "Sam Lee" || len(last.Specialties) == 0 {
+		t.Errorf("last saved %q with specialties %v, want Sam Lee with his profile", last.Title, last.Specialties)
+	}
+}
+
+func TestFetchFailedResults(t *testing.T) {
+	srv := fixtures(t)
+	srv.fail("results-2.html", 2)
+
+	repo, _, err := fetchFixtures(t, context.Background(), srv, fetch.Config{MaxAttempts: 2})
+	if err == nil || len(repo.failed) != 1 || repo.failed[0].Profile {
+		t.Fatalf("got error %v and failed pages %+v, want the second results page", err, repo.failed)
+	}
+
+	// A later fetch of the whole search forgets the page once it succeeds.
+	_, err = fetchInto(t, context.Background(), repo, srv, fetch.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repo.failed) != 0 {
+		t.Fatalf("got failed pages %+v after fetching the search again, want none", repo.failed)
+	}
+
+	srv.fail("results-2.html", 2)
+	_, err = fetchInto(t, context.Background(), repo, srv, fetch.Config{MaxAttempts: 2})
+	if err == nil || len(repo.failed) != 1 {
+		t.Fatalf("got error %v and failed pages %+v, want the second results page", err, repo.failed)
+	}
+
+	first := srv.served["results-1.html"]
+	stats, err := fetchInto(t, context.Background(), repo, srv, fetch.Config{Failed: repo.failed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Pages != 2 || srv.served["results-1.html"] != first {
+		t.Errorf("fetched %d pages and the first results page %d more times, want only the failed page and its profile", stats.Pages, srv.served["results-1.html"]-first)
+	}
+}
+
+// database returns a repository backed by a new SQLite database.
+func database(t *testing.T) therapy.Repository {
+	t.Helper()
+
+	ctx := context.Background()
+	repo := sqlite.NewRepository("file:"+filepath.Join(t.TempDir(), "psych.db"), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := repo.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	return repo
+}
+
+func TestFetchChanges(t *testing.T) {
+	repo := database(t)
+	srv := fixtures(t)
+
+	stats, err := fetchInto(t, context.Background(), repo, srv, fetch.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.New != 3 || stats.Updated != 0 || stats.Unchanged != 0 {
+		t.Errorf("first fetch saved %d new, %d updated and %d unchanged therapists, want 3 new", stats.New, stats.Updated, stats.Unchanged)
+	}
+
+	stats, err = fetchInto(t, context.Background(), repo, srv, fetch.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.New != 0 || stats.Updated != 0 || stats.Unchanged != 3 {
+		t.Errorf("second fetch saved %d new, %d updated and %d unchanged therapists, want 3 unchanged", stats.New, stats.Updated, stats.Unchanged)
+	}
+
+	srv.edit("results-1.html", "(425) 555-0101", "(425) 555-0199")
+
+	stats, err = fetchInto(t, context.Background(), repo, srv, fetch.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Updated != 1 || stats.Unchanged != 2 {
+		t.Errorf("third fetch saved %d updated and %d unchanged therapists, want 1 and 2", stats.Updated, stats.Unchanged)
+	}
+
+	title := "Jane Doe"
+	found, err := repo.Find(context.Background(), &api.GetTherapistParams{Title: &title})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("found %d therapists named %s: %v", len(found), title, err)
+	}
+
+	history, err := repo.History(context.Background(), found[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []api.FieldChange{{Field: "phone", Old: "(425) 555-0101", New: "(425) 555-0199"}}
+	if len(history) != 1 || !reflect.DeepEqual(history[0].Changes, want) {
+		t.Errorf("got history %+v, want one revision changing the phone", history)
+	}
+}
+
+func TestFetchProfileKept(t *testing.T) {
+	repo := database(t)
+	srv := fixtures(t)
+
+	_, err := fetchInto(t, context.Background(), repo, srv, fetch.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Jane Doe's profile can't be fetched again, so only the details from
+	// the search results are refreshed.
+	srv.fail("profile-111111.html", 100)
+	_, err = fetchInto(t, context.Background(), repo, srv, fetch.Config{})
+	var urlErr *fetch.URLError
+	if !errors.As(err, &urlErr) || !urlErr.Profile {
+		t.Fatalf("got error %v, want a profile URLError", err)
+	}
+
+	title := "Jane Doe"
+	found, err := repo.Find(context.Background(), &api.GetTherapistParams{Title: &title})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("found %d therapists named %s: %v", len(found), title, err)
+	}
+
+	jane := found[0]
+	if jane.Fees != "Individual Sessions: $150; Couple Sessions: $180" || !jane.InPerson || !jane.Telehealth {
+		t.Errorf("got fees %q, in person %t and telehealth %t, want the details from the first fetch", jane.Fees, jane.InPerson, jane.Telehealth)
+	}
+
+	history, err := repo.History(context.Background(), jane.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 0 {
+		t.Errorf("got history %+v, want none", history)
+	}
+}
+
+func TestFetchResume(t *testing.T) {
+	ctx := context.Background()
+	repo := database(t)
+	srv := fixtures(t)
+	crawl, err := repo.CreateCrawl(ctx, api.Crawl{Region: srv.URL + "/us/therapists/98027", Source: Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupt the crawl as soon as it reaches the second results page.
+	interrupted, cancel := context.WithCancel(ctx)
+	defer cancel()
+	srv.onServe = func(name string) {
+		if name == "results-2.html" {
+			cancel()
+		}
+	}
+
+	_, err = fetchInto(t, interrupted, repo, srv, fetch.Config{Crawl: crawl.ID})
+	if fetch.Complete(err) {
+		t.Fatalf("got error %v, want the fetch to be cancelled", err)
+	}
+
+	if _, err := repo.UnfinishedCrawl(ctx, crawl.Region); err != nil {
+		t.Fatalf("interrupted crawl is not unfinished: %v", err)
+	}
+
+	srv.mu.Lock()
+	srv.onServe = nil
+	srv.mu.Unlock()
+
+	_, err = fetchInto(t, ctx, repo, srv, fetch.Config{Crawl: crawl.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if srv.served["results-1.html"] != 1 {
+		t.Errorf("first results page was fetched again when resuming")
+	}
+
+	for _, profile := range []string{"111111", "222222", "333333"} {
+		if srv.served["profile-"+profile+".html"] == 0 {
+			t.Errorf("profile %s was never fetched", profile)
+		}
+	}
+
+	if _, err := repo.UnfinishedCrawl(ctx, crawl.Region); !errors.Is(err, sqlite.ErrCrawlNotFound) {
+		t.Errorf("got error %v for the resumed crawl, want it finished", err)
+	}
+
+	therapists, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(therapists) != 3 {
+		t.Fatalf("saved %d therapists, want 3", len(therapists))
+	}
+
+	for _, profile := range []bool{false, true} {
+		pending, err := repo.PendingCrawlPages(ctx, crawl.ID, profile, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(pending) > 0 {
+			t.Errorf("%d pages are still pending after resuming", len(pending))
+		}
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	sel := source{}.Selectors()
+
+	results, err := fetch.CheckResults(source{}, sel, page(t, "/us/therapists/98027", "results-1.html"), page(t, "/us/therapists/98027?page=2", "results-2.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.Total != 3 {
+		t.Errorf("found %d results, want 3", results.Total)
+	}
+
+	if len(results.Profiles) != 3 {
+		t.Errorf("found %d profile links, want 3", len(results.Profiles))
+	}
+
+	for _, f := range results.Fields {
+		if f.Empty() {
+			t.Errorf("result field %q came back empty", f.Field)
+		}
+	}
+
+	profiles, err := fetch.CheckProfiles(sel, page(t, "/us/therapists/jane-doe-issaquah-wa/111111", "profile-111111.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range profiles.Fields {
+		if f.Empty() {
+			t.Errorf("profile field %q came back empty", f.Field)
+		}
+	}
+}
+
+func page(t *testing.T, path, name string) fetch.Page {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fetch.Page{URL: origin + path, Body: b}
+}
+
+// golden compares got with the JSON in testdata/name, or rewrites the file
+// when the tests are run with -update.
+func golden(t *testing.T, name string, got []api.Therapist) {
+	t.Helper()
+
+	b, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = append(b, '\n')
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		err := os.WriteFile(path, b, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run go test -update to create it", err)
+	}
+
+	if !bytes.Equal(b, want) {
+		t.Errorf("therapists differ from %s, run go test -update if the change is expected\ngot:\n%s", path, b)
+	}
+}
@@ -0,0 +1,62 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/brittonhayes/therapy"
+	"github.com/brittonhayes/therapy/api"
+)
+
+// crawlQueue is a queue.Storage that keeps the search results pages of a
+// crawl in the repository, so an interrupted crawl can be resumed. Pages
+// stay pending until the fetcher marks them done or failed, and the ones
+// handed out before an interruption are handed out again on resume.
+type crawlQueue struct {
+	ctx   context.Context
+	repo  therapy.Repository
+	crawl int
+
+	// mu guards last, the ID of the page last handed to the queue.
+	mu   sync.Mutex
+	last int
+}
+
+func (q *crawlQueue) Init() error {
+	return nil
+}
+
+func (q *crawlQueue) AddRequest(request []byte) error {
+	var r struct{ URL string }
+	err := json.Unmarshal(request, &r)
+	if err != nil {
+		return err
+	}
+
+	return q.repo.QueueCrawlPage(q.ctx, api.CrawlPage{
+		CrawlID: q.crawl,
+		URL:     r.URL,
+		Request: request,
+	})
+}
+
+func (q *crawlQueue) GetRequest() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pages, err := q.repo.PendingCrawlPages(q.ctx, q.crawl, false, q.last, 1)
+	if err != nil || len(pages) == 0 {
+		return nil, err
+	}
+
+	q.last = pages[0].ID
+	return pages[0].Request, nil
+}
+
+func (q *crawlQueue) QueueSize() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.repo.CountPendingCrawlPages(q.ctx, q.crawl, false, q.last)
+}
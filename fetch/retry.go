@@ -0,0 +1,75 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = time.Second
+
+	// maxBackoff caps the exponential backoff between attempts.
+	maxBackoff = time.Minute
+)
+
+// retryable reports whether a failed request may succeed if it's sent again,
+// because the host was unreachable, overloaded or rate limiting.
+func retryable(r *colly.Response) bool {
+	return r.StatusCode == 0 || r.StatusCode == http.StatusTooManyRequests || r.StatusCode >= 500
+}
+
+// backoff returns how long to wait before retrying a request for the given
+// time, starting at 1. The wait doubles with each retry, unless the response
+// says how long to wait with a Retry-After header.
+func backoff(r *colly.Response, base time.Duration, retry int) time.Duration {
+	if wait, ok := retryAfter(r); ok {
+		return wait
+	}
+
+	wait := base << (retry - 1)
+	if wait <= 0 || wait > maxBackoff {
+		wait = maxBackoff
+	}
+
+	return wait
+}
+
+// retryAfter reads a Retry-After header given in seconds or as a date.
+func retryAfter(r *colly.Response) (time.Duration, bool) {
+	if r.Headers == nil {
+		return 0, false
+	}
+
+	value := r.Headers.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
+
+// sleep waits for d, and reports false if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
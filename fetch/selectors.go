@@ -0,0 +1,163 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// SelectorsVersion is the selector profile format this build understands.
+const SelectorsVersion = 1
+
+var ErrSelectorsVersion = errors.New("unsupported selector profile version")
+
+// Selectors is a versioned profile of the CSS selectors a source scrapes
+// with. Sources embed a default profile, and a profile loaded from a file can
+// override it so scraping keeps working when a site's markup changes.
+type Selectors struct {
+	Version int    `yaml:"version" json:"version"`
+	Source  string `yaml:"source" json:"source"`
+	// Results matches each therapist on a search results page.
+	Results string `yaml:"results" json:"results"`
+	// Pagination matches links to further search results pages.
+	Pagination Selector `yaml:"pagination" json:"pagination"`
+	// Result fields are matched within each search result.
+	Result SelectorSet `yaml:"result" json:"result"`
+	// Profile fields are matched on a therapist's profile page.
+	Profile SelectorSet `yaml:"profile" json:"profile"`
+}
+
+// Selector matches an element. Its text is used unless Attr is set.
+type Selector struct {
+	CSS  string `yaml:"css" json:"css"`
+	Attr string `yaml:"attr,omitempty" json:"attr,omitempty"`
+	// Required selectors are expected to match on every page with results,
+	// and a warning is logged when they don't.
+	Required bool `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
+// SelectorSet maps field names to the selectors they are scraped with.
+type SelectorSet map[string]Selector
+
+// ParseSelectors parses a YAML or JSON selector profile.
+func ParseSelectors(b []byte) (Selectors, error) {
+	var s Selectors
+	err := yaml.Unmarshal(b, &s)
+	if err != nil {
+		return s, err
+	}
+
+	if s.Version != SelectorsVersion {
+		return s, fmt.Errorf("%w %d, expected %d", ErrSelectorsVersion, s.Version, SelectorsVersion)
+	}
+
+	return s, nil
+}
+
+// LoadSelectors reads a YAML or JSON selector profile from a file.
+func LoadSelectors(path string) (Selectors, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Selectors{}, err
+	}
+
+	s, err := ParseSelectors(b)
+	if err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// Merge returns a copy of s with every selector set in override replacing
+// its counterpart, so an override file only needs the selectors that changed.
+func (s Selectors) Merge(override Selectors) (Selectors, error) {
+	if override.Source != "" && override.Source != s.Source {
+		return s, fmt.Errorf("selector profile is for source %q, not %q", override.Source, s.Source)
+	}
+
+	merged := s
+	if override.Results != "" {
+		merged.Results = override.Results
+	}
+
+	if override.Pagination.CSS != "" {
+		merged.Pagination = override.Pagination
+	}
+
+	merged.Result = s.Result.merge(override.Result)
+	merged.Profile = s.Profile.merge(override.Profile)
+
+	return merged, nil
+}
+
+func (s SelectorSet) merge(override SelectorSet) SelectorSet {
+	merged := make(SelectorSet, len(s)+len(override))
+	for field, sel := range s {
+		merged[field] = sel
+	}
+
+	for field, sel := range override {
+		merged[field] = sel
+	}
+
+	return merged
+}
+
+// Text returns the text, or attribute, of the elements within e matching the
+// field's selector. It returns an empty string for unknown fields.
+func (s SelectorSet) Text(e *colly.HTMLElement, field string) string {
+	sel, ok := s[field]
+	if !ok || sel.CSS == "" {
+		return ""
+	}
+
+	if sel.Attr != "" {
+		return strings.TrimSpace(e.ChildAttr(sel.CSS, sel.Attr))
+	}
+
+	return e.ChildText(sel.CSS)
+}
+
+// Texts returns the text, or attribute, of each element within e matching the
+// field's selector.
+func (s SelectorSet) Texts(e *colly.HTMLElement, field string) []string {
+	sel, ok := s[field]
+	if !ok || sel.CSS == "" {
+		return []string{}
+	}
+
+	if sel.Attr != "" {
+		return e.ChildAttrs(sel.CSS, sel.Attr)
+	}
+
+	return e.ChildTexts(sel.CSS)
+}
+
+// missing returns the required fields whose selectors match nothing within
+// any of the elements matched by scope, in sorted order.
+func (s SelectorSet) missing(e *colly.HTMLElement, scope string) []string {
+	var fields []string
+	for field, sel := range s {
+		if !sel.Required || sel.CSS == "" {
+			continue
+		}
+
+		matched := e.DOM.Find(sel.CSS)
+		if scope != "" {
+			matched = e.DOM.Find(scope).Find(sel.CSS)
+		}
+
+		if matched.Length() == 0 {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	return fields
+}
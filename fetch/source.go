@@ -0,0 +1,94 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/gocolly/colly/v2"
+)
+
+var (
+	ErrUnknownSource   = errors.New("unknown source")
+	ErrIncompleteQuery = errors.New("not enough flags provided to generate web scraping URL")
+)
+
+// Query describes the area and insurer to search a directory for.
+type Query struct {
+	Country   string
+	State     string
+	County    string
+	City      string
+	Zip       string
+	Insurance string
+}
+
+// Source is a therapist directory that can be crawled. Each directory lives
+// in its own package and registers itself with Register.
+type Source interface {
+	// Name identifies the source. It is recorded on every therapist fetched
+	// from it.
+	Name() string
+	// Domains lists the hosts the crawler is allowed to visit.
+	Domains() []string
+	// URL builds the search results URL for a query.
+	URL(q Query) (string, error)
+	// Selectors returns the source's default selector profile.
+	Selectors() Selectors
+	// ParseResult reads a therapist from a search result matched by the
+	// profile's Results selector. Results with a link have their profile
+	// page visited next.
+	ParseResult(e *colly.HTMLElement, sel Selectors) api.Therapist
+	// Pages returns the URLs of further search results pages linked from a
+	// results page.
+	Pages(e *colly.HTMLElement, sel Selectors) []string
+	// ParseProfile fills in the therapist's details from their profile page.
+	ParseProfile(e *colly.HTMLElement, sel Selectors, therapist *api.Therapist)
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = map[string]Source{}
+)
+
+// Register makes a source available by name. It panics if a source with the
+// same name is already registered.
+func Register(s Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+
+	if _, ok := sources[s.Name()]; ok {
+		panic("fetch: source registered twice: " + s.Name())
+	}
+
+	sources[s.Name()] = s
+}
+
+// Lookup returns the registered source with the given name.
+func Lookup(name string) (Source, error) {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	s, ok := sources[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSource, name)
+	}
+
+	return s, nil
+}
+
+// Sources returns the names of the registered sources in sorted order.
+func Sources() []string {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,107 @@
+//go:build ignore
+
+// This program downloads the US Census Bureau ZIP Code Tabulation Area
+// gazetteer and writes the internal point of every ZCTA to zipcodes.csv.gz.
+// The gazetteer is a public domain work of the US government.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+const source = "https://www2.census.gov/geo/docs/maps-data/data/gazetteer/2023_Gazetteer/2023_Gaz_zcta_national.zip"
+
+func main() {
+	resp, err := http.Get(source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("downloading %s: %s", source, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var gazetteer *zip.File
+	for _, f := range archive.File {
+		if path.Ext(f.Name) == ".txt" {
+			gazetteer = f
+		}
+	}
+	if gazetteer == nil {
+		log.Fatal(errors.New("gazetteer file not found in archive"))
+	}
+
+	r, err := gazetteer.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	out, err := os.Create("zipcodes.csv.gz")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	w := csv.NewWriter(gz)
+
+	err = w.Write([]string{"zip", "latitude", "longitude"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, record := range records[1:] {
+		err := w.Write([]string{
+			strings.TrimSpace(record[columns["GEOID"]]),
+			strings.TrimSpace(record[columns["INTPTLAT"]]),
+			strings.TrimSpace(record[columns["INTPTLONG"]]),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package geo geocodes US zip codes offline and measures distances between
+// points on the earth's surface.
+package geo
+
+//go:generate go run gen.go
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// EarthRadiusMiles is the mean radius of the earth used for distances.
+const EarthRadiusMiles = 3958.8
+
+var ErrUnknownZip = errors.New("unknown zip code")
+
+// zipcodes is a gzipped CSV of zip code centroids, regenerated from the US
+// Census Bureau ZCTA gazetteer with go generate.
+//
+//go:embed zipcodes.csv.gz
+var zipcodes []byte
+
+var zipPattern = regexp.MustCompile(`\b(\d{5})(?:-\d{4})?\b`)
+
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type Geocoder struct {
+	points map[string]Point
+}
+
+// NewGeocoder reads zip code centroids from a CSV with zip, latitude and
+// longitude columns and a header row.
+func NewGeocoder(r io.Reader) (*Geocoder, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Geocoder{points: make(map[string]Point, len(records))}
+	for i, record := range records {
+		if i == 0 || len(record) < 3 {
+			continue
+		}
+
+		lat, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, err
+		}
+
+		lng, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, err
+		}
+
+		g.points[record[0]] = Point{Latitude: lat, Longitude: lng}
+	}
+
+	return g, nil
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultGeocoder *Geocoder
+)
+
+// Default returns a geocoder backed by the bundled zip code dataset.
+func Default() *Geocoder {
+	defaultOnce.Do(func() {
+		r, err := gzip.NewReader(bytes.NewReader(zipcodes))
+		if err != nil {
+			panic(err)
+		}
+		defer r.Close()
+
+		defaultGeocoder, err = NewGeocoder(r)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	return defaultGeocoder
+}
+
+// Len returns the number of zip codes the geocoder knows about.
+func (g *Geocoder) Len() int {
+	return len(g.points)
+}
+
+// Lookup returns the centroid of the zip code.
+func (g *Geocoder) Lookup(zip string) (Point, error) {
+	p, ok := g.points[zip]
+	if !ok {
+		return Point{}, ErrUnknownZip
+	}
+
+	return p, nil
+}
+
+// Locate returns the centroid of the zip code found in a postal address.
+func (g *Geocoder) Locate(address string) (Point, error) {
+	zip := Zip(address)
+	if zip == "" {
+		return Point{}, ErrUnknownZip
+	}
+
+	return g.Lookup(zip)
+}
+
+// Zip returns the last five digit zip code in a postal address, or an empty
+// string if there isn't one.
+func Zip(address string) string {
+	matches := zipPattern.FindAllStringSubmatch(address, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return matches[len(matches)-1][1]
+}
+
+// Distance returns the great-circle distance in miles between two points.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dlat := lat2 - lat1
+	dlng := radians(b.Longitude - a.Longitude)
+
+	h := math.Pow(math.Sin(dlat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dlng/2), 2)
+	return 2 * EarthRadiusMiles * math.Asin(math.Sqrt(h))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,32 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDefaultLookup(t *testing.T) {
+	g := Default()
+	if g.Len() == 0 {
+		t.Fatal("the bundled zip code dataset is empty, run go generate ./geo")
+	}
+
+	// Issaquah, WA.
+	p, err := g.Lookup("98027")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(p.Latitude-47.5) > 0.2 || math.Abs(p.Longitude+122.0) > 0.2 {
+		t.Errorf("98027 is at %v, want near Issaquah, WA", p)
+	}
+
+	p, err = g.Locate("123 Front St N, Issaquah, WA 98027")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := Distance(p, Point{Latitude: 47.53, Longitude: -122.03}); d > 10 {
+		t.Errorf("98027 is %.1f miles from Issaquah, want under 10", d)
+	}
+}
@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/brittonhayes/therapy"
+	"github.com/brittonhayes/therapy/api"
+)
+
+// therapistParams converts GraphQL therapist filters and order into
+// repository parameters.
+func therapistParams(filter *therapy.TherapistFilters, order *therapy.TherapistOrder) *api.GetTherapistParams {
+	if filter == nil && order == nil {
+		return nil
+	}
+
+	if filter == nil {
+		return &api.GetTherapistParams{OrderBy: therapistOrder(order)}
+	}
+
+	return &api.GetTherapistParams{
+		OrderBy:               therapistOrder(order),
+		Search:                filter.Search,
+		Title:                 filter.Title,
+		AcceptingAppointments: filter.AcceptingAppointments,
+		Credentials:           filter.Credentials,
+		Verified:              filter.Verified,
+		Statement:             filter.Statement,
+		Phone:                 filter.Phone,
+		Location:              filter.Location,
+		Link:                  filter.Link,
+		Specialties:           listFilter(filter.Specialties),
+		Issues:                listFilter(filter.Issues),
+		Modalities:            listFilter(filter.Modalities),
+		AgeGroups:             listFilter(filter.AgeGroups),
+		Languages:             listFilter(filter.Languages),
+		Insurance:             listFilter(filter.Insurance),
+		Near:                  near(filter.Near),
+		InPerson:              filter.InPerson,
+		Telehealth:            filter.Telehealth,
+		Region:                filter.Region,
+		Source:                filter.Source,
+		Stale:                 filter.Stale,
+		FirstSeenAfter:        filter.FirstSeenAfter,
+		FirstSeenBefore:       filter.FirstSeenBefore,
+		LastSeenAfter:         filter.LastSeenAfter,
+		LastSeenBefore:        filter.LastSeenBefore,
+		Tags:                  listFilter(filter.Tags),
+		MinRating:             filter.MinRating,
+		ContactStatus:         contactStatuses(filter.ContactStatus),
+		Shortlist:             filter.Shortlist,
+		Limit:                 filter.Limit,
+		Offset:                filter.Offset,
+	}
+}
+
+// listFilter converts a GraphQL list filter into its repository equivalent.
+func listFilter(filter *therapy.ListFilter) *api.ListFilter {
+	if filter == nil {
+		return nil
+	}
+
+	match := api.MatchAny
+	if filter.Match != nil && *filter.Match == therapy.MatchAll {
+		match = api.MatchAll
+	}
+
+	return &api.ListFilter{
+		Values: filter.Values,
+		Match:  match,
+	}
+}
+
+// near converts a GraphQL near filter into its repository equivalent.
+func near(filter *therapy.Near) *api.Near {
+	if filter == nil {
+		return nil
+	}
+
+	return &api.Near{
+		Zip:         filter.Zip,
+		Latitude:    filter.Lat,
+		Longitude:   filter.Lng,
+		RadiusMiles: filter.RadiusMiles,
+	}
+}
+
+// therapistOrder converts a GraphQL therapist order into its repository
+// equivalent.
+func therapistOrder(order *therapy.TherapistOrder) *api.OrderBy {
+	if order == nil {
+		return nil
+	}
+
+	o := &api.OrderBy{Direction: api.Ascending}
+	if order.Direction != nil && *order.Direction == therapy.OrderDirectionDesc {
+		o.Direction = api.Descending
+	}
+
+	switch order.Field {
+	case therapy.TherapistOrderFieldTitle:
+		o.Field = api.OrderByTitle
+	case therapy.TherapistOrderFieldCredentials:
+		o.Field = api.OrderByCredentials
+	case therapy.TherapistOrderFieldLastSeen:
+		o.Field = api.OrderByLastSeen
+	case therapy.TherapistOrderFieldDistance:
+		o.Field = api.OrderByDistance
+	case therapy.TherapistOrderFieldRelevance:
+		o.Field = api.OrderByRelevance
+	}
+
+	return o
+}
+
+// contactStatus converts a GraphQL contact status into its repository
+// equivalent.
+func contactStatus(status therapy.ContactStatus) api.ContactStatus {
+	return api.ContactStatus(strings.ToLower(string(status)))
+}
+
+// contactStatuses converts a list of GraphQL contact statuses into their
+// repository equivalents.
+func contactStatuses(statuses []therapy.ContactStatus) []api.ContactStatus {
+	if statuses == nil {
+		return nil
+	}
+
+	converted := make([]api.ContactStatus, len(statuses))
+	for i, s := range statuses {
+		converted[i] = contactStatus(s)
+	}
+
+	return converted
+}
+
+// parseID converts a GraphQL ID argument into a repository ID.
+func parseID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", id)
+	}
+
+	return n, nil
+}
@@ -6,31 +6,222 @@ package graph
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/brittonhayes/therapy"
 	"github.com/brittonhayes/therapy/api"
 )
 
+// FinishedAt is the resolver for the finished_at field.
+func (r *crawlRunResolver) FinishedAt(ctx context.Context, obj *api.CrawlRun) (*time.Time, error) {
+	if obj.FinishedAt.IsZero() {
+		return nil, nil
+	}
+
+	return &obj.FinishedAt, nil
+}
+
+// AddNote is the resolver for the addNote field.
+func (r *mutationResolver) AddNote(ctx context.Context, therapistID string, body string) (api.Note, error) {
+	id, err := parseID(therapistID)
+	if err != nil {
+		return api.Note{}, err
+	}
+
+	return r.Repo.AddNote(ctx, id, body)
+}
+
+// DeleteNote is the resolver for the deleteNote field.
+func (r *mutationResolver) DeleteNote(ctx context.Context, id string) (bool, error) {
+	n, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.Repo.DeleteNote(ctx, n)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AddTag is the resolver for the addTag field.
+func (r *mutationResolver) AddTag(ctx context.Context, therapistID string, tag string) (api.Therapist, error) {
+	id, err := parseID(therapistID)
+	if err != nil {
+		return api.Therapist{}, err
+	}
+
+	err = r.Repo.AddTag(ctx, id, tag)
+	if err != nil {
+		return api.Therapist{}, err
+	}
+
+	return r.Repo.Get(ctx, id)
+}
+
+// RemoveTag is the resolver for the removeTag field.
+func (r *mutationResolver) RemoveTag(ctx context.Context, therapistID string, tag string) (api.Therapist, error) {
+	id, err := parseID(therapistID)
+	if err != nil {
+		return api.Therapist{}, err
+	}
+
+	err = r.Repo.RemoveTag(ctx, id, tag)
+	if err != nil {
+		return api.Therapist{}, err
+	}
+
+	return r.Repo.Get(ctx, id)
+}
+
+// SetRating is the resolver for the setRating field.
+func (r *mutationResolver) SetRating(ctx context.Context, therapistID string, rating *int) (api.Therapist, error) {
+	id, err := parseID(therapistID)
+	if err != nil {
+		return api.Therapist{}, err
+	}
+
+	err = r.Repo.SetRating(ctx, id, rating)
+	if err != nil {
+		return api.Therapist{}, err
+	}
+
+	return r.Repo.Get(ctx, id)
+}
+
+// SetContactStatus is the resolver for the setContactStatus field.
+func (r *mutationResolver) SetContactStatus(ctx context.Context, therapistID string, status therapy.ContactStatus) (api.Therapist, error) {
+	id, err := parseID(therapistID)
+	if err != nil {
+		return api.Therapist{}, err
+	}
+
+	err = r.Repo.SetContactStatus(ctx, id, contactStatus(status))
+	if err != nil {
+		return api.Therapist{}, err
+	}
+
+	return r.Repo.Get(ctx, id)
+}
+
+// CreateShortlist is the resolver for the createShortlist field.
+func (r *mutationResolver) CreateShortlist(ctx context.Context, name string) (api.Shortlist, error) {
+	return r.Repo.CreateShortlist(ctx, name)
+}
+
+// AddToShortlist is the resolver for the addToShortlist field.
+func (r *mutationResolver) AddToShortlist(ctx context.Context, name string, therapistID string) (api.Shortlist, error) {
+	id, err := parseID(therapistID)
+	if err != nil {
+		return api.Shortlist{}, err
+	}
+
+	err = r.Repo.AddToShortlist(ctx, name, id)
+	if err != nil {
+		return api.Shortlist{}, err
+	}
+
+	return r.Repo.Shortlist(ctx, name)
+}
+
+// RemoveFromShortlist is the resolver for the removeFromShortlist field.
+func (r *mutationResolver) RemoveFromShortlist(ctx context.Context, name string, therapistID string) (api.Shortlist, error) {
+	id, err := parseID(therapistID)
+	if err != nil {
+		return api.Shortlist{}, err
+	}
+
+	err = r.Repo.RemoveFromShortlist(ctx, name, id)
+	if err != nil {
+		return api.Shortlist{}, err
+	}
+
+	return r.Repo.Shortlist(ctx, name)
+}
+
 // Therapists is the resolver for the therapists field.
-func (r *queryResolver) Therapists(ctx context.Context, filter *therapy.TherapistFilters) ([]api.Therapist, error) {
-	if filter == nil {
+func (r *queryResolver) Therapists(ctx context.Context, filter *therapy.TherapistFilters, orderBy *therapy.TherapistOrder) ([]api.Therapist, error) {
+	params := therapistParams(filter, orderBy)
+	if params == nil {
 		return r.Repo.List(ctx)
 	}
 
-	return r.Repo.Find(ctx, &api.GetTherapistParams{
-		Title:       filter.Title,
-		Credentials: filter.Credentials,
-		Verified:    filter.Verified,
-		Statement:   filter.Statement,
-		Phone:       filter.Phone,
-		Location:    filter.Location,
-		Link:        filter.Link,
-		Limit:       filter.Limit,
-		Offset:      filter.Offset,
+	return r.Repo.Find(ctx, params)
+}
+
+// TherapistsConnection is the resolver for the therapistsConnection field.
+func (r *queryResolver) TherapistsConnection(ctx context.Context, first *int, after *string, last *int, before *string, filter *therapy.TherapistFilters, orderBy *therapy.TherapistOrder) (api.TherapistConnection, error) {
+	return r.Repo.FindPage(ctx, therapistParams(filter, orderBy), api.Page{
+		First:  first,
+		After:  after,
+		Last:   last,
+		Before: before,
 	})
 }
 
+// Search is the resolver for the search field.
+func (r *queryResolver) Search(ctx context.Context, query string, limit *int) ([]api.SearchResult, error) {
+	if limit == nil {
+		return r.Repo.Search(ctx, query, 0)
+	}
+
+	return r.Repo.Search(ctx, query, *limit)
+}
+
+// Shortlists is the resolver for the shortlists field.
+func (r *queryResolver) Shortlists(ctx context.Context) ([]api.Shortlist, error) {
+	return r.Repo.Shortlists(ctx)
+}
+
+// Shortlist is the resolver for the shortlist field.
+func (r *queryResolver) Shortlist(ctx context.Context, name string) (api.Shortlist, error) {
+	return r.Repo.Shortlist(ctx, name)
+}
+
+// CrawlRuns is the resolver for the crawlRuns field.
+func (r *queryResolver) CrawlRuns(ctx context.Context, limit *int) ([]api.CrawlRun, error) {
+	if limit == nil {
+		return r.Repo.CrawlRuns(ctx, 0)
+	}
+
+	return r.Repo.CrawlRuns(ctx, *limit)
+}
+
+// Therapists is the resolver for the therapists field.
+func (r *shortlistResolver) Therapists(ctx context.Context, obj *api.Shortlist) ([]api.Therapist, error) {
+	return r.Repo.Find(ctx, &api.GetTherapistParams{Shortlist: &obj.Name})
+}
+
+// ContactStatus is the resolver for the contact_status field.
+func (r *therapistResolver) ContactStatus(ctx context.Context, obj *api.Therapist) (therapy.ContactStatus, error) {
+	return therapy.ContactStatus(strings.ToUpper(string(obj.ContactStatus))), nil
+}
+
+// History is the resolver for the history field.
+func (r *therapistResolver) History(ctx context.Context, obj *api.Therapist) ([]api.Revision, error) {
+	return r.Repo.History(ctx, obj.ID)
+}
+
+// CrawlRun returns CrawlRunResolver implementation.
+func (r *Resolver) CrawlRun() CrawlRunResolver { return &crawlRunResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Shortlist returns ShortlistResolver implementation.
+func (r *Resolver) Shortlist() ShortlistResolver { return &shortlistResolver{r} }
+
+// Therapist returns TherapistResolver implementation.
+func (r *Resolver) Therapist() TherapistResolver { return &therapistResolver{r} }
+
+type crawlRunResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type shortlistResolver struct{ *Resolver }
+type therapistResolver struct{ *Resolver }
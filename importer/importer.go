@@ -0,0 +1,338 @@
+// Package importer reads therapists from CSV and JSON files, such as
+// insurer directory spreadsheets, using a configurable column mapping.
+package importer
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+// Format is an import file format.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown import format")
+	ErrUnknownField  = errors.New("unknown therapist field")
+)
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case CSV:
+		return CSV, nil
+	case JSON:
+		return JSON, nil
+	}
+
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, name)
+}
+
+// Mapping maps therapist fields to the column names or JSON keys they are
+// read from. Fields without a mapping are read from a column with the same
+// name as the field, which matches the columns written by psych export.
+type Mapping map[string]string
+
+// field is a therapist field that can be imported.
+type field struct {
+	name string
+	set  func(t *api.Therapist, value string, separator string) error
+}
+
+func text(set func(t *api.Therapist, v string)) func(*api.Therapist, string, string) error {
+	return func(t *api.Therapist, v string, _ string) error {
+		set(t, v)
+		return nil
+	}
+}
+
+func boolean(set func(t *api.Therapist, v bool)) func(*api.Therapist, string, string) error {
+	return func(t *api.Therapist, v string, _ string) error {
+		b, err := parseBool(v)
+		if err != nil {
+			return err
+		}
+		set(t, b)
+		return nil
+	}
+}
+
+func list(set func(t *api.Therapist, v []string)) func(*api.Therapist, string, string) error {
+	return func(t *api.Therapist, v string, separator string) error {
+		values := []string{}
+		for _, s := range strings.Split(v, separator) {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+		set(t, values)
+		return nil
+	}
+}
+
+var fields = []field{
+	{"title", text(func(t *api.Therapist, v string) { t.Title = v })},
+	{"credentials", text(func(t *api.Therapist, v string) { t.Credentials = v })},
+	{"accepting_appointments", boolean(func(t *api.Therapist, v bool) { t.AcceptingAppointments = v })},
+	{"verified", text(func(t *api.Therapist, v string) { t.Verified = v })},
+	{"phone", text(func(t *api.Therapist, v string) { t.Phone = v })},
+	{"location", text(func(t *api.Therapist, v string) { t.Location = v })},
+	{"link", text(func(t *api.Therapist, v string) { t.Link = v })},
+	{"statement", text(func(t *api.Therapist, v string) { t.Statement = v })},
+	{"fees", text(func(t *api.Therapist, v string) { t.Fees = v })},
+	{"in_person", boolean(func(t *api.Therapist, v bool) { t.InPerson = v })},
+	{"telehealth", boolean(func(t *api.Therapist, v bool) { t.Telehealth = v })},
+	{"insurance", list(func(t *api.Therapist, v []string) { t.Insurance = v })},
+	{"specialties", list(func(t *api.Therapist, v []string) { t.Specialties = v })},
+	{"issues", list(func(t *api.Therapist, v []string) { t.Issues = v })},
+	{"modalities", list(func(t *api.Therapist, v []string) { t.Modalities = v })},
+	{"age_groups", list(func(t *api.Therapist, v []string) { t.AgeGroups = v })},
+	{"languages", list(func(t *api.Therapist, v []string) { t.Languages = v })},
+	{"region", text(func(t *api.Therapist, v string) { t.Region = v })},
+}
+
+// Fields lists the therapist fields that can be mapped.
+func Fields() []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// Validate reports an error if the mapping names a field that can't be
+// imported.
+func (m Mapping) Validate() error {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !known(name) {
+			return fmt.Errorf("%w %q, must be one of %s", ErrUnknownField, name, strings.Join(Fields(), ", "))
+		}
+	}
+
+	return nil
+}
+
+func known(name string) bool {
+	for _, f := range fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// column returns the column the field is read from.
+func (m Mapping) column(name string) string {
+	if c, ok := m[name]; ok {
+		return c
+	}
+	return name
+}
+
+// Reader reads therapists from a file.
+type Reader struct {
+	Format  Format
+	Mapping Mapping
+	// Separator splits list-valued fields such as specialties. It defaults
+	// to ";".
+	Separator string
+	// Source is recorded on every therapist read, and is part of the
+	// generated link of rows that don't have one.
+	Source string
+}
+
+// Read reads every therapist from r. Rows without a profile link are given a
+// stable one derived from their name, phone and location, so importing the
+// same file again updates them instead of adding duplicates. Each therapist
+// records the fields its row had, so saving it leaves the others as they were.
+func (rd Reader) Read(r io.Reader) ([]api.Therapist, error) {
+	err := rd.Mapping.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	switch rd.Format {
+	case CSV:
+		rows, err = readCSV(r)
+	case JSON:
+		rows, err = readJSON(r, rd.separator())
+	default:
+		err = fmt.Errorf("%w %q", ErrUnknownFormat, rd.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	therapists := make([]api.Therapist, 0, len(rows))
+	for i, row := range rows {
+		t := api.Therapist{Source: rd.Source, Profiled: true}
+		for _, f := range fields {
+			value, ok := lookup(row, rd.Mapping.column(f.name))
+			if !ok {
+				continue
+			}
+			t.Fields = append(t.Fields, f.name)
+
+			err := f.set(&t, strings.TrimSpace(value), rd.separator())
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %s: %w", i+1, f.name, err)
+			}
+		}
+
+		if t.Title == "" {
+			return nil, fmt.Errorf("row %d: missing title", i+1)
+		}
+
+		if t.Link == "" {
+			t.Link = rd.link(t)
+		}
+
+		therapists = append(therapists, t)
+	}
+
+	return therapists, nil
+}
+
+func (rd Reader) separator() string {
+	if rd.Separator == "" {
+		return ";"
+	}
+	return rd.Separator
+}
+
+// link generates a stable identifier for a therapist without a profile link.
+func (rd Reader) link(t api.Therapist) string {
+	key := strings.ToLower(strings.Join([]string{strings.TrimSpace(t.Title), digits(t.Phone), strings.TrimSpace(t.Location)}, "|"))
+	return fmt.Sprintf("urn:psych:%s:%x", rd.Source, sha1.Sum([]byte(key)))
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// lookup finds a column ignoring case and surrounding whitespace.
+func lookup(row map[string]string, column string) (string, bool) {
+	if v, ok := row[column]; ok {
+		return v, true
+	}
+
+	for k, v := range row {
+		if strings.EqualFold(strings.TrimSpace(k), strings.TrimSpace(column)) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+func readCSV(r io.Reader) ([]map[string]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	var rows []map[string]string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// readJSON reads an array of objects. Values are converted to strings, and
+// arrays are joined with the list separator.
+func readJSON(r io.Reader, separator string) ([]map[string]string, error) {
+	var objects []map[string]interface{}
+	err := json.NewDecoder(r).Decode(&objects)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]string, 0, len(objects))
+	for _, o := range objects {
+		row := make(map[string]string, len(o))
+		for k, v := range o {
+			row[k] = stringify(v, separator)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func stringify(v interface{}, separator string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = stringify(e, separator)
+		}
+		return strings.Join(values, separator)
+	}
+
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func parseBool(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "", "false", "f", "no", "n", "0":
+		return false, nil
+	case "true", "t", "yes", "y", "1", "x":
+		return true, nil
+	}
+
+	return false, fmt.Errorf("invalid boolean %q", v)
+}
@@ -2,6 +2,27 @@
 
 package therapy
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type ListFilter struct {
+	Values []string `json:"values"`
+	Match  *Match   `json:"match,omitempty"`
+}
+
+// Restricts results to therapists within radius_miles of a zip code or of a
+// lat/lng point. Results are sorted nearest first.
+type Near struct {
+	Zip         *string  `json:"zip,omitempty"`
+	Lat         *float64 `json:"lat,omitempty"`
+	Lng         *float64 `json:"lng,omitempty"`
+	RadiusMiles float64  `json:"radius_miles"`
+}
+
 type TherapistFilters struct {
 	Title                 *string `json:"title,omitempty"`
 	AcceptingAppointments *bool   `json:"accepting_appointments,omitempty"`
@@ -11,6 +32,210 @@ type TherapistFilters struct {
 	Phone                 *string `json:"phone,omitempty"`
 	Location              *string `json:"location,omitempty"`
 	Link                  *string `json:"link,omitempty"`
-	Limit                 *int    `json:"limit,omitempty"`
-	Offset                *int    `json:"offset,omitempty"`
+	// Full-text search terms matched against names, credentials, statements and locations.
+	Search          *string         `json:"search,omitempty"`
+	Specialties     *ListFilter     `json:"specialties,omitempty"`
+	Issues          *ListFilter     `json:"issues,omitempty"`
+	Modalities      *ListFilter     `json:"modalities,omitempty"`
+	AgeGroups       *ListFilter     `json:"age_groups,omitempty"`
+	Languages       *ListFilter     `json:"languages,omitempty"`
+	Insurance       *ListFilter     `json:"insurance,omitempty"`
+	Near            *Near           `json:"near,omitempty"`
+	InPerson        *bool           `json:"in_person,omitempty"`
+	Telehealth      *bool           `json:"telehealth,omitempty"`
+	Region          *string         `json:"region,omitempty"`
+	Source          *string         `json:"source,omitempty"`
+	Stale           *bool           `json:"stale,omitempty"`
+	FirstSeenAfter  *time.Time      `json:"first_seen_after,omitempty"`
+	FirstSeenBefore *time.Time      `json:"first_seen_before,omitempty"`
+	LastSeenAfter   *time.Time      `json:"last_seen_after,omitempty"`
+	LastSeenBefore  *time.Time      `json:"last_seen_before,omitempty"`
+	Tags            *ListFilter     `json:"tags,omitempty"`
+	MinRating       *int            `json:"min_rating,omitempty"`
+	ContactStatus   []ContactStatus `json:"contact_status,omitempty"`
+	// Only therapists on the shortlist with this name.
+	Shortlist *string `json:"shortlist,omitempty"`
+	Limit     *int    `json:"limit,omitempty"`
+	Offset    *int    `json:"offset,omitempty"`
+}
+
+type TherapistOrder struct {
+	Field     TherapistOrderField `json:"field"`
+	Direction *OrderDirection     `json:"direction,omitempty"`
+}
+
+type ContactStatus string
+
+const (
+	ContactStatusNotContacted  ContactStatus = "NOT_CONTACTED"
+	ContactStatusLeftVoicemail ContactStatus = "LEFT_VOICEMAIL"
+	ContactStatusScheduled     ContactStatus = "SCHEDULED"
+	ContactStatusDeclined      ContactStatus = "DECLINED"
+)
+
+var AllContactStatus = []ContactStatus{
+	ContactStatusNotContacted,
+	ContactStatusLeftVoicemail,
+	ContactStatusScheduled,
+	ContactStatusDeclined,
+}
+
+func (e ContactStatus) IsValid() bool {
+	switch e {
+	case ContactStatusNotContacted, ContactStatusLeftVoicemail, ContactStatusScheduled, ContactStatusDeclined:
+		return true
+	}
+	return false
+}
+
+func (e ContactStatus) String() string {
+	return string(e)
+}
+
+func (e *ContactStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ContactStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ContactStatus", str)
+	}
+	return nil
+}
+
+func (e ContactStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Match string
+
+const (
+	MatchAny Match = "ANY"
+	MatchAll Match = "ALL"
+)
+
+var AllMatch = []Match{
+	MatchAny,
+	MatchAll,
+}
+
+func (e Match) IsValid() bool {
+	switch e {
+	case MatchAny, MatchAll:
+		return true
+	}
+	return false
+}
+
+func (e Match) String() string {
+	return string(e)
+}
+
+func (e *Match) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Match(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Match", str)
+	}
+	return nil
+}
+
+func (e Match) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderDirection string
+
+const (
+	OrderDirectionAsc  OrderDirection = "ASC"
+	OrderDirectionDesc OrderDirection = "DESC"
+)
+
+var AllOrderDirection = []OrderDirection{
+	OrderDirectionAsc,
+	OrderDirectionDesc,
+}
+
+func (e OrderDirection) IsValid() bool {
+	switch e {
+	case OrderDirectionAsc, OrderDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e OrderDirection) String() string {
+	return string(e)
+}
+
+func (e *OrderDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderDirection", str)
+	}
+	return nil
+}
+
+func (e OrderDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TherapistOrderField string
+
+const (
+	TherapistOrderFieldTitle       TherapistOrderField = "TITLE"
+	TherapistOrderFieldCredentials TherapistOrderField = "CREDENTIALS"
+	TherapistOrderFieldLastSeen    TherapistOrderField = "LAST_SEEN"
+	// Requires a near filter.
+	TherapistOrderFieldDistance TherapistOrderField = "DISTANCE"
+	// Requires a search filter. Use DESC to put the best matches first.
+	TherapistOrderFieldRelevance TherapistOrderField = "RELEVANCE"
+)
+
+var AllTherapistOrderField = []TherapistOrderField{
+	TherapistOrderFieldTitle,
+	TherapistOrderFieldCredentials,
+	TherapistOrderFieldLastSeen,
+	TherapistOrderFieldDistance,
+	TherapistOrderFieldRelevance,
+}
+
+func (e TherapistOrderField) IsValid() bool {
+	switch e {
+	case TherapistOrderFieldTitle, TherapistOrderFieldCredentials, TherapistOrderFieldLastSeen, TherapistOrderFieldDistance, TherapistOrderFieldRelevance:
+		return true
+	}
+	return false
+}
+
+func (e TherapistOrderField) String() string {
+	return string(e)
+}
+
+func (e *TherapistOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TherapistOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TherapistOrderField", str)
+	}
+	return nil
+}
+
+func (e TherapistOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -0,0 +1,240 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrNotFound            = errors.New("therapist not found")
+	ErrNoteNotFound        = errors.New("note not found")
+	ErrInvalidRating       = errors.New("rating must be between 1 and 5")
+	ErrInvalidContactState = errors.New("invalid contact status")
+	ErrEmptyTag            = errors.New("tag must not be empty")
+)
+
+// annotation holds the user owned rating and contact status of a therapist.
+// It lives in its own table so that re-fetching a therapist never touches it.
+type annotation struct {
+	bun.BaseModel `bun:"table:therapist_annotations"`
+
+	TherapistID   int               `bun:"therapist_id,pk"`
+	Rating        *int              `bun:"rating"`
+	ContactStatus api.ContactStatus `bun:"contact_status"`
+	UpdatedAt     time.Time         `bun:"updated_at"`
+}
+
+// exists returns ErrNotFound unless a therapist with the ID exists.
+func (r *repository) exists(ctx context.Context, id int) error {
+	ok, err := r.db.NewSelect().Model((*api.Therapist)(nil)).Where("id = ?", id).Exists(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) Get(ctx context.Context, id int) (api.Therapist, error) {
+	var therapist api.Therapist
+	err := r.db.NewSelect().Model(&therapist).Where("id = ?", id).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return therapist, ErrNotFound
+	}
+	if err != nil {
+		return therapist, err
+	}
+
+	therapists := []api.Therapist{therapist}
+	err = r.loadRelations(ctx, therapists)
+	if err != nil {
+		return therapist, err
+	}
+
+	return therapists[0], nil
+}
+
+func (r *repository) AddNote(ctx context.Context, therapistID int, body string) (api.Note, error) {
+	note := api.Note{
+		TherapistID: therapistID,
+		Body:        body,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if err := r.exists(ctx, therapistID); err != nil {
+		return note, err
+	}
+
+	_, err := r.db.NewInsert().Model(&note).Returning("id").Exec(ctx)
+	if err != nil {
+		return note, err
+	}
+
+	return note, nil
+}
+
+func (r *repository) DeleteNote(ctx context.Context, id int) error {
+	res, err := r.db.NewDelete().Model((*api.Note)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoteNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) AddTag(ctx context.Context, therapistID int, tag string) error {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return ErrEmptyTag
+	}
+
+	if err := r.exists(ctx, therapistID); err != nil {
+		return err
+	}
+
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(&attributeName{Name: tag}).
+			ModelTableExpr("tags").
+			On("CONFLICT (name) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewRaw("INSERT INTO therapist_tags (therapist_id, tag_id) SELECT ?, id FROM tags WHERE name = ? ON CONFLICT DO NOTHING",
+			therapistID, tag,
+		).Exec(ctx)
+		return err
+	})
+}
+
+func (r *repository) RemoveTag(ctx context.Context, therapistID int, tag string) error {
+	if err := r.exists(ctx, therapistID); err != nil {
+		return err
+	}
+
+	_, err := r.db.NewDelete().
+		TableExpr("therapist_tags").
+		Where("therapist_id = ?", therapistID).
+		Where("tag_id IN (SELECT id FROM tags WHERE name = ?)", strings.TrimSpace(tag)).
+		Exec(ctx)
+	return err
+}
+
+// SetRating sets the therapist's star rating from 1 to 5. A nil rating clears
+// it.
+func (r *repository) SetRating(ctx context.Context, therapistID int, rating *int) error {
+	if rating != nil && (*rating < 1 || *rating > 5) {
+		return ErrInvalidRating
+	}
+
+	return r.saveAnnotation(ctx, &annotation{TherapistID: therapistID, Rating: rating}, "rating")
+}
+
+func (r *repository) SetContactStatus(ctx context.Context, therapistID int, status api.ContactStatus) error {
+	if !status.Valid() {
+		return ErrInvalidContactState
+	}
+
+	return r.saveAnnotation(ctx, &annotation{TherapistID: therapistID, ContactStatus: status}, "contact_status")
+}
+
+// saveAnnotation upserts the given column of the therapist's annotation,
+// leaving the others as they were.
+func (r *repository) saveAnnotation(ctx context.Context, a *annotation, column string) error {
+	if err := r.exists(ctx, a.TherapistID); err != nil {
+		return err
+	}
+
+	if a.ContactStatus == "" {
+		a.ContactStatus = api.NotContacted
+	}
+	a.UpdatedAt = time.Now().UTC()
+
+	_, err := r.db.NewInsert().
+		Model(a).
+		On("CONFLICT (therapist_id) DO UPDATE").
+		Set("? = EXCLUDED.?", bun.Ident(column), bun.Ident(column)).
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	return err
+}
+
+// annotationFilterQuery restricts the query to therapists matching the
+// rating and contact status filters in params.
+func (r *repository) annotationFilterQuery(query *bun.SelectQuery, params *api.GetTherapistParams) *bun.SelectQuery {
+	if params.MinRating != nil {
+		query.Where("EXISTS (SELECT 1 FROM therapist_annotations AS an WHERE an.therapist_id = ?TableAlias.id AND an.rating >= ?)", *params.MinRating)
+	}
+
+	if len(params.ContactStatus) > 0 {
+		query.Where("COALESCE((SELECT an.contact_status FROM therapist_annotations AS an WHERE an.therapist_id = ?TableAlias.id), ?) IN (?)",
+			api.NotContacted, bun.In(params.ContactStatus),
+		)
+	}
+
+	return query
+}
+
+// loadAnnotations populates the rating, contact status and notes of the
+// given therapists.
+func (r *repository) loadAnnotations(ctx context.Context, therapists []api.Therapist) error {
+	if len(therapists) == 0 {
+		return nil
+	}
+
+	index := make(map[int]*api.Therapist, len(therapists))
+	ids := make([]int, 0, len(therapists))
+	for i := range therapists {
+		therapists[i].ContactStatus = api.NotContacted
+		therapists[i].Notes = []api.Note{}
+		index[therapists[i].ID] = &therapists[i]
+		ids = append(ids, therapists[i].ID)
+	}
+
+	var annotations []annotation
+	err := r.db.NewSelect().Model(&annotations).Where("therapist_id IN (?)", bun.In(ids)).Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range annotations {
+		if t, ok := index[a.TherapistID]; ok {
+			t.Rating = a.Rating
+			t.ContactStatus = a.ContactStatus
+		}
+	}
+
+	var notes []api.Note
+	err = r.db.NewSelect().Model(&notes).Where("therapist_id IN (?)", bun.In(ids)).Order("created_at", "id").Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range notes {
+		if t, ok := index[n.TherapistID]; ok {
+			t.Notes = append(t.Notes, n)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+// attribute is a list-valued therapist detail stored in its own lookup table
+// and linked to therapists through a join table. User owned attributes are
+// never written by Save, so a re-fetch can't overwrite them.
+type attribute struct {
+	// name is the therapist field the attribute is stored in.
+	name      string
+	table     string
+	join      string
+	column    string
+	field     func(t *api.Therapist) *[]string
+	filter    func(p *api.GetTherapistParams) *api.ListFilter
+	userOwned bool
+}
+
+var attributes = []attribute{
+	{
+		name:   "specialties",
+		table:  "specialties",
+		join:   "therapist_specialties",
+		column: "specialty_id",
+		field:  func(t *api.Therapist) *[]string { return &t.Specialties },
+		filter: func(p *api.GetTherapistParams) *api.ListFilter { return p.Specialties },
+	},
+	{
+		name:   "issues",
+		table:  "issues",
+		join:   "therapist_issues",
+		column: "issue_id",
+		field:  func(t *api.Therapist) *[]string { return &t.Issues },
+		filter: func(p *api.GetTherapistParams) *api.ListFilter { return p.Issues },
+	},
+	{
+		name:   "modalities",
+		table:  "modalities",
+		join:   "therapist_modalities",
+		column: "modality_id",
+		field:  func(t *api.Therapist) *[]string { return &t.Modalities },
+		filter: func(p *api.GetTherapistParams) *api.ListFilter { return p.Modalities },
+	},
+	{
+		name:   "age_groups",
+		table:  "age_groups",
+		join:   "therapist_age_groups",
+		column: "age_group_id",
+		field:  func(t *api.Therapist) *[]string { return &t.AgeGroups },
+		filter: func(p *api.GetTherapistParams) *api.ListFilter { return p.AgeGroups },
+	},
+	{
+		name:   "languages",
+		table:  "languages",
+		join:   "therapist_languages",
+		column: "language_id",
+		field:  func(t *api.Therapist) *[]string { return &t.Languages },
+		filter: func(p *api.GetTherapistParams) *api.ListFilter { return p.Languages },
+	},
+	{
+		name:   "insurance",
+		table:  "insurances",
+		join:   "therapist_insurances",
+		column: "insurance_id",
+		field:  func(t *api.Therapist) *[]string { return &t.Insurance },
+		filter: func(p *api.GetTherapistParams) *api.ListFilter { return p.Insurance },
+	},
+	{
+		name:      "tags",
+		table:     "tags",
+		join:      "therapist_tags",
+		column:    "tag_id",
+		field:     func(t *api.Therapist) *[]string { return &t.Tags },
+		filter:    func(p *api.GetTherapistParams) *api.ListFilter { return p.Tags },
+		userOwned: true,
+	},
+}
+
+type attributeName struct {
+	ID   int    `bun:"id,pk,autoincrement"`
+	Name string `bun:"name"`
+}
+
+// attributeFilterQuery restricts the query to therapists matching the list
+// filters in params. With api.MatchAll every value must be linked to the
+// therapist, otherwise any one of them is enough.
+func (r *repository) attributeFilterQuery(query *bun.SelectQuery, params *api.GetTherapistParams) *bun.SelectQuery {
+	for _, a := range attributes {
+		filter := a.filter(params)
+		if filter == nil || len(filter.Values) == 0 {
+			continue
+		}
+
+		if filter.Match == api.MatchAll {
+			for _, v := range filter.Values {
+				query.Where("EXISTS (?)", r.attributeSubquery(a, v))
+			}
+			continue
+		}
+
+		query.Where("EXISTS (?)", r.attributeSubquery(a, filter.Values...))
+	}
+
+	return query
+}
+
+// likeEscaper escapes the LIKE wildcards in a value so it's matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// attributeSubquery selects the links between the outer therapist and any
+// attribute whose name contains one of values.
+func (r *repository) attributeSubquery(a attribute, values ...string) *bun.SelectQuery {
+	return r.db.NewSelect().
+		ColumnExpr("1").
+		TableExpr("? AS j", bun.Ident(a.join)).
+		Join("JOIN ? AS a ON a.id = j.?", bun.Ident(a.table), bun.Ident(a.column)).
+		Where("j.therapist_id = ?TableAlias.id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, v := range values {
+				q.WhereOr(`a.name LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(v)+"%")
+			}
+			return q
+		})
+}
+
+// saveAttributes replaces the attributes linked to the therapist. A nil list
+// means the attribute was not scraped, so the existing links are kept.
+func (r *repository) saveAttributes(ctx context.Context, tx bun.Tx, therapist *api.Therapist) error {
+	for _, a := range attributes {
+		values := *a.field(therapist)
+		if values == nil || a.userOwned {
+			continue
+		}
+
+		_, err := tx.NewDelete().
+			TableExpr("?", bun.Ident(a.join)).
+			Where("therapist_id = ?", therapist.ID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		names := make([]attributeName, 0, len(values))
+		for _, v := range values {
+			names = append(names, attributeName{Name: v})
+		}
+
+		_, err = tx.NewInsert().
+			Model(&names).
+			ModelTableExpr("?", bun.Ident(a.table)).
+			On("CONFLICT (name) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewRaw("INSERT INTO ? (therapist_id, ?) SELECT ?, id FROM ? WHERE name IN (?) ON CONFLICT DO NOTHING",
+			bun.Ident(a.join), bun.Ident(a.column), therapist.ID, bun.Ident(a.table), bun.In(values),
+		).Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadAttributes populates the attribute lists of the given therapists.
+func (r *repository) loadAttributes(ctx context.Context, therapists []api.Therapist) error {
+	if len(therapists) == 0 {
+		return nil
+	}
+
+	index := make(map[int]*api.Therapist, len(therapists))
+	ids := make([]int, 0, len(therapists))
+	for i := range therapists {
+		index[therapists[i].ID] = &therapists[i]
+		ids = append(ids, therapists[i].ID)
+	}
+
+	for _, a := range attributes {
+		var rows []struct {
+			TherapistID int    `bun:"therapist_id"`
+			Name        string `bun:"name"`
+		}
+
+		err := r.db.NewSelect().
+			ColumnExpr("j.therapist_id, a.name").
+			TableExpr("? AS j", bun.Ident(a.join)).
+			Join("JOIN ? AS a ON a.id = j.?", bun.Ident(a.table), bun.Ident(a.column)).
+			Where("j.therapist_id IN (?)", bun.In(ids)).
+			OrderExpr("a.name").
+			Scan(ctx, &rows)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range therapists {
+			*a.field(index[t.ID]) = []string{}
+		}
+
+		for _, row := range rows {
+			t, ok := index[row.TherapistID]
+			if !ok {
+				continue
+			}
+
+			field := a.field(t)
+			*field = append(*field, row.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+func TestListFilterLiteral(t *testing.T) {
+	repo := database(t)
+	ctx := context.Background()
+
+	_, err := repo.SaveBatch(ctx, []api.Therapist{
+		{Title: "Percent", Link: "a", Insurance: []string{"Sliding scale 50% off"}},
+		{Title: "Plain", Link: "b", Insurance: []string{"Sliding scale 50 off"}},
+		{Title: "Underscore", Link: "c", Languages: []string{"ASL_signed"}},
+		{Title: "Spaced", Link: "d", Languages: []string{"ASL signed"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		params api.GetTherapistParams
+		want   []string
+	}{
+		{api.GetTherapistParams{Insurance: &api.ListFilter{Values: []string{"50%"}}}, []string{"Percent"}},
+		{api.GetTherapistParams{Insurance: &api.ListFilter{Values: []string{"50"}}}, []string{"Percent", "Plain"}},
+		{api.GetTherapistParams{Languages: &api.ListFilter{Values: []string{"ASL_"}, Match: api.MatchAll}}, []string{"Underscore"}},
+	}
+
+	for _, tt := range tests {
+		found, err := repo.Find(ctx, &tt.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := titles(found); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("got %v, want %v", got, tt.want)
+		}
+	}
+}
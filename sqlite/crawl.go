@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+var ErrCrawlNotFound = errors.New("no unfinished crawl found")
+
+func (r *repository) CreateCrawl(ctx context.Context, crawl api.Crawl) (api.Crawl, error) {
+	if crawl.StartedAt.IsZero() {
+		crawl.StartedAt = time.Now()
+	}
+	crawl.StartedAt = crawl.StartedAt.UTC()
+
+	_, err := r.db.NewInsert().Model(&crawl).Returning("id").Exec(ctx)
+	return crawl, err
+}
+
+// UnfinishedCrawl returns the most recently started crawl of the region that
+// hasn't finished, or of any region if region is empty.
+func (r *repository) UnfinishedCrawl(ctx context.Context, region string) (api.Crawl, error) {
+	var crawl api.Crawl
+	q := r.db.NewSelect().Model(&crawl).Where("finished_at IS NULL")
+	if region != "" {
+		q = q.Where("region = ?", region)
+	}
+
+	err := q.Order("started_at DESC", "id DESC").Limit(1).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return crawl, ErrCrawlNotFound
+	}
+
+	return crawl, err
+}
+
+func (r *repository) FinishCrawl(ctx context.Context, id int) error {
+	_, err := r.db.NewUpdate().
+		Model((*api.Crawl)(nil)).
+		Set("finished_at = ?", time.Now().UTC()).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+// QueueCrawlPage adds a page to a crawl. A page already in the crawl is left
+// as it is, so a page done before an interruption isn't fetched again when
+// the crawl is resumed.
+func (r *repository) QueueCrawlPage(ctx context.Context, page api.CrawlPage) error {
+	if page.State == "" {
+		page.State = api.PagePending
+	}
+	page.UpdatedAt = time.Now().UTC()
+
+	_, err := r.db.NewInsert().
+		Model(&page).
+		On("CONFLICT (crawl_id, url) DO NOTHING").
+		Exec(ctx)
+	return err
+}
+
+func (r *repository) SetCrawlPageState(ctx context.Context, crawlID int, url string, state api.PageState) error {
+	_, err := r.db.NewUpdate().
+		Model((*api.CrawlPage)(nil)).
+		Set("state = ?", state).
+		Set("updated_at = ?", time.Now().UTC()).
+		Where("crawl_id = ?", crawlID).
+		Where("url = ?", url).
+		Exec(ctx)
+	return err
+}
+
+// PendingCrawlPages returns up to limit of the crawl's pending profiles, or
+// its pending search results pages, queued after the page with the ID after,
+// in the order they were queued. A limit of zero returns all of them.
+func (r *repository) PendingCrawlPages(ctx context.Context, crawlID int, profile bool, after int, limit int) ([]api.CrawlPage, error) {
+	var pages []api.CrawlPage
+	q := pendingQuery(r.db.NewSelect().Model(&pages), crawlID, profile, after).Order("id")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	err := q.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// CountPendingCrawlPages counts the pages PendingCrawlPages would return
+// without a limit.
+func (r *repository) CountPendingCrawlPages(ctx context.Context, crawlID int, profile bool, after int) (int, error) {
+	return pendingQuery(r.db.NewSelect().Model((*api.CrawlPage)(nil)), crawlID, profile, after).Count(ctx)
+}
+
+// pendingQuery restricts the query to the crawl's pending profiles or search
+// results pages queued after the page with the ID after.
+func pendingQuery(query *bun.SelectQuery, crawlID int, profile bool, after int) *bun.SelectQuery {
+	return query.
+		Where("crawl_id = ?", crawlID).
+		Where("profile = ?", profile).
+		Where("state = ?", api.PagePending).
+		Where("id > ?", after)
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+func TestPendingCrawlPages(t *testing.T) {
+	repo := database(t)
+	ctx := context.Background()
+
+	crawl, err := repo.CreateCrawl(ctx, api.Crawl{Region: "region", Source: "source"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		err := repo.QueueCrawlPage(ctx, api.CrawlPage{CrawlID: crawl.ID, URL: fmt.Sprintf("page-%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.SetCrawlPageState(ctx, crawl.ID, "page-2", api.PageDone); err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := repo.PendingCrawlPages(ctx, crawl.ID, false, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 || pages[0].URL != "page-1" {
+		t.Fatalf("got %+v, want only page-1", pages)
+	}
+
+	pages, err = repo.PendingCrawlPages(ctx, crawl.ID, false, pages[0].ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 || pages[0].URL != "page-3" {
+		t.Errorf("got %+v after page-1, want page-3", pages)
+	}
+
+	n, err := repo.CountPendingCrawlPages(ctx, crawl.ID, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("counted %d pending pages, want 2", n)
+	}
+}
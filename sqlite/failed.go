@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+// SaveFailedPage records a page that failed to fetch. If the page already
+// failed before, its record is updated and its attempts are added to the
+// earlier ones.
+func (r *repository) SaveFailedPage(ctx context.Context, page api.FailedPage) error {
+	if page.FailedAt.IsZero() {
+		page.FailedAt = time.Now()
+	}
+	page.FailedAt = page.FailedAt.UTC()
+
+	_, err := r.db.NewInsert().
+		Model(&page).
+		On("CONFLICT (url) DO UPDATE").
+		Set("region = EXCLUDED.region").
+		Set("source = EXCLUDED.source").
+		Set("profile = EXCLUDED.profile").
+		Set("error = EXCLUDED.error").
+		Set("attempts = ?TableAlias.attempts + EXCLUDED.attempts").
+		Set("failed_at = EXCLUDED.failed_at").
+		Exec(ctx)
+	return err
+}
+
+func (r *repository) FailedPages(ctx context.Context) ([]api.FailedPage, error) {
+	var pages []api.FailedPage
+	err := r.db.NewSelect().Model(&pages).Order("region", "id").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// DeleteFailedPage removes a page from the failed pages, once it has been
+// fetched successfully.
+func (r *repository) DeleteFailedPage(ctx context.Context, url string) error {
+	_, err := r.db.NewDelete().Model((*api.FailedPage)(nil)).Where("url = ?", url).Exec(ctx)
+	return err
+}
@@ -3,12 +3,27 @@ package migrations
 import (
 	"context"
 
-	"github.com/brittonhayes/therapy/api"
 	"github.com/uptrace/bun"
 )
 
+// therapist is a snapshot of api.Therapist as it was when this migration was
+// written. Columns added since then are created by their own migrations.
+type therapist struct {
+	bun.BaseModel `bun:"table:therapists"`
+
+	ID                    int `bun:"id,pk,autoincrement"`
+	Title                 string
+	AcceptingAppointments string
+	Credentials           string
+	Verified              string
+	Statement             string
+	Phone                 string
+	Location              string
+	Link                  string
+}
+
 var models = []interface{}{
-	(*api.Therapist)(nil),
+	(*therapist)(nil),
 }
 
 func init() {
@@ -0,0 +1,104 @@
+package migrations
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/uptrace/bun"
+)
+
+// origin is the site links scraped before this migration are relative to.
+const origin = "https://www.psychologytoday.com"
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			// Earlier versions saved profile links as scraped, relative and
+			// with tracking parameters, so rewrite them the way they're
+			// scraped now before comparing them.
+			var therapists []therapist
+			err := tx.NewSelect().Model(&therapists).Column("id", "link").Scan(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, t := range therapists {
+				link := profileLink(t.Link)
+				if link == t.Link {
+					continue
+				}
+
+				_, err := tx.NewUpdate().
+					Model((*therapist)(nil)).
+					Set("link = ?", link).
+					Where("id = ?", t.ID).
+					Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Earlier versions also inserted a new row on every fetch, so
+			// collapse any duplicates onto the oldest row before enforcing
+			// uniqueness.
+			_, err = tx.NewDelete().
+				Model((*therapist)(nil)).
+				Where("id NOT IN (?)", tx.NewSelect().
+					Model((*therapist)(nil)).
+					ColumnExpr("MIN(id)").
+					Group("link"),
+				).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.NewCreateIndex().
+				Model((*therapist)(nil)).
+				Index("therapists_link_idx").
+				Unique().
+				IfNotExists().
+				Column("link").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropIndex().
+			Model((*therapist)(nil)).
+			Index("therapists_link_idx").
+			IfExists().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// profileLink resolves a scraped profile link against the site and strips its
+// tracking parameters and fragment.
+func profileLink(link string) string {
+	if link == "" {
+		return link
+	}
+
+	base, err := url.Parse(origin)
+	if err != nil {
+		return link
+	}
+
+	u, err := base.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return u.String()
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			columns := []string{
+				"region VARCHAR",
+				"first_seen_at TIMESTAMP",
+				"last_seen_at TIMESTAMP",
+				"stale BOOLEAN NOT NULL DEFAULT FALSE",
+			}
+
+			for _, column := range columns {
+				_, err := tx.NewAddColumn().Model((*therapist)(nil)).ColumnExpr(column).Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Rows fetched before this migration have no history, so treat
+			// them as first and last seen now.
+			now := time.Now().UTC()
+			_, err := tx.NewUpdate().
+				Model((*therapist)(nil)).
+				Set("first_seen_at = ?", now).
+				Set("last_seen_at = ?", now).
+				Where("1 = 1").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, column := range []string{"region", "first_seen_at", "last_seen_at", "stale"} {
+				_, err := tx.NewDropColumn().Model((*therapist)(nil)).Column(column).Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
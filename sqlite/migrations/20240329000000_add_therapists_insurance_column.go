@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewAddColumn().Model((*therapist)(nil)).ColumnExpr("insurance VARCHAR").Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropColumn().Model((*therapist)(nil)).Column("insurance").Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
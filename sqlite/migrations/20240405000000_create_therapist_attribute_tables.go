@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// profileAttributeTables maps each lookup table of profile attributes to the join
+// table and column that link it to therapists.
+var profileAttributeTables = []struct {
+	table  string
+	join   string
+	column string
+}{
+	{table: "specialties", join: "therapist_specialties", column: "specialty_id"},
+	{table: "issues", join: "therapist_issues", column: "issue_id"},
+	{table: "modalities", join: "therapist_modalities", column: "modality_id"},
+	{table: "age_groups", join: "therapist_age_groups", column: "age_group_id"},
+	{table: "languages", join: "therapist_languages", column: "language_id"},
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			columns := []string{
+				"fees VARCHAR",
+				"in_person BOOLEAN NOT NULL DEFAULT FALSE",
+				"telehealth BOOLEAN NOT NULL DEFAULT FALSE",
+			}
+
+			for _, column := range columns {
+				_, err := tx.NewAddColumn().Model((*therapist)(nil)).ColumnExpr(column).Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, t := range profileAttributeTables {
+				_, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS ? (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					name VARCHAR NOT NULL UNIQUE
+				)`, bun.Ident(t.table))
+				if err != nil {
+					return err
+				}
+
+				_, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS ? (
+					therapist_id INTEGER NOT NULL REFERENCES therapists (id) ON DELETE CASCADE,
+					? INTEGER NOT NULL REFERENCES ? (id) ON DELETE CASCADE,
+					PRIMARY KEY (therapist_id, ?)
+				)`, bun.Ident(t.join), bun.Ident(t.column), bun.Ident(t.table), bun.Ident(t.column))
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, t := range profileAttributeTables {
+				_, err := tx.NewDropTable().Table(t.join).IfExists().Exec(ctx)
+				if err != nil {
+					return err
+				}
+
+				_, err = tx.NewDropTable().Table(t.table).IfExists().Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, column := range []string{"fees", "in_person", "telehealth"} {
+				_, err := tx.NewDropColumn().Model((*therapist)(nil)).Column(column).Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
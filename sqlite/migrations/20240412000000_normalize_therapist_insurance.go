@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			_, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS insurances (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name VARCHAR NOT NULL UNIQUE
+			)`)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS therapist_insurances (
+				therapist_id INTEGER NOT NULL REFERENCES therapists (id) ON DELETE CASCADE,
+				insurance_id INTEGER NOT NULL REFERENCES insurances (id) ON DELETE CASCADE,
+				PRIMARY KEY (therapist_id, insurance_id)
+			)`)
+			if err != nil {
+				return err
+			}
+
+			// Move the JSON encoded insurance lists into the new tables.
+			_, err = tx.ExecContext(ctx, `INSERT INTO insurances (name)
+				SELECT DISTINCT e.value FROM therapists AS t, json_each(t.insurance) AS e
+				WHERE t.insurance IS NOT NULL
+				ON CONFLICT (name) DO NOTHING`)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `INSERT INTO therapist_insurances (therapist_id, insurance_id)
+				SELECT t.id, i.id FROM therapists AS t, json_each(t.insurance) AS e
+				JOIN insurances AS i ON i.name = e.value
+				WHERE t.insurance IS NOT NULL
+				ON CONFLICT DO NOTHING`)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.NewDropColumn().Model((*therapist)(nil)).Column("insurance").Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			_, err := tx.NewAddColumn().Model((*therapist)(nil)).ColumnExpr("insurance VARCHAR").Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `UPDATE therapists SET insurance = (
+				SELECT json_group_array(i.name) FROM therapist_insurances AS ti
+				JOIN insurances AS i ON i.id = ti.insurance_id
+				WHERE ti.therapist_id = therapists.id
+			) WHERE id IN (SELECT therapist_id FROM therapist_insurances)`)
+			if err != nil {
+				return err
+			}
+
+			for _, table := range []string{"therapist_insurances", "insurances"} {
+				_, err = tx.NewDropTable().Table(table).IfExists().Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			_, err := tx.NewAddColumn().
+				Model((*therapist)(nil)).
+				ColumnExpr("accepting BOOLEAN NOT NULL DEFAULT FALSE").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Mirrors the parsing done by the fetcher for newly scraped rows.
+			_, err = tx.ExecContext(ctx, `UPDATE therapists SET accepting = CASE
+				WHEN lower(accepting_appointments) LIKE '%not accepting%' THEN FALSE
+				WHEN lower(accepting_appointments) LIKE '%waitlist%' THEN FALSE
+				WHEN lower(accepting_appointments) LIKE '%accepting%' THEN TRUE
+				ELSE FALSE
+			END`)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.NewDropColumn().Model((*therapist)(nil)).Column("accepting_appointments").Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, "ALTER TABLE therapists RENAME COLUMN accepting TO accepting_appointments")
+			if err != nil {
+				return err
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			_, err := tx.ExecContext(ctx, "ALTER TABLE therapists RENAME COLUMN accepting_appointments TO accepting")
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.NewAddColumn().Model((*therapist)(nil)).ColumnExpr("accepting_appointments VARCHAR").Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `UPDATE therapists SET accepting_appointments = CASE
+				WHEN accepting THEN 'Accepting new clients'
+				ELSE ''
+			END`)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.NewDropColumn().Model((*therapist)(nil)).Column("accepting").Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			statements := []string{
+				`CREATE VIRTUAL TABLE IF NOT EXISTS therapists_fts USING fts5(
+					title, credentials, statement, location,
+					content='therapists', content_rowid='id'
+				)`,
+				`CREATE TRIGGER IF NOT EXISTS therapists_fts_insert AFTER INSERT ON therapists BEGIN
+					INSERT INTO therapists_fts (rowid, title, credentials, statement, location)
+					VALUES (new.id, new.title, new.credentials, new.statement, new.location);
+				END`,
+				`CREATE TRIGGER IF NOT EXISTS therapists_fts_delete AFTER DELETE ON therapists BEGIN
+					INSERT INTO therapists_fts (therapists_fts, rowid, title, credentials, statement, location)
+					VALUES ('delete', old.id, old.title, old.credentials, old.statement, old.location);
+				END`,
+				`CREATE TRIGGER IF NOT EXISTS therapists_fts_update AFTER UPDATE ON therapists BEGIN
+					INSERT INTO therapists_fts (therapists_fts, rowid, title, credentials, statement, location)
+					VALUES ('delete', old.id, old.title, old.credentials, old.statement, old.location);
+					INSERT INTO therapists_fts (rowid, title, credentials, statement, location)
+					VALUES (new.id, new.title, new.credentials, new.statement, new.location);
+				END`,
+				// Index the rows that existed before the table was created.
+				`INSERT INTO therapists_fts (therapists_fts) VALUES ('rebuild')`,
+			}
+
+			for _, statement := range statements {
+				_, err := tx.ExecContext(ctx, statement)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			statements := []string{
+				"DROP TRIGGER IF EXISTS therapists_fts_insert",
+				"DROP TRIGGER IF EXISTS therapists_fts_delete",
+				"DROP TRIGGER IF EXISTS therapists_fts_update",
+				"DROP TABLE IF EXISTS therapists_fts",
+			}
+
+			for _, statement := range statements {
+				_, err := tx.ExecContext(ctx, statement)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/brittonhayes/therapy/geo"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, column := range []string{"latitude REAL", "longitude REAL"} {
+				_, err := tx.NewAddColumn().Model((*therapist)(nil)).ColumnExpr(column).Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err := tx.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS therapists_coordinates_idx ON therapists (latitude, longitude)")
+			if err != nil {
+				return err
+			}
+
+			var rows []struct {
+				ID       int    `bun:"id"`
+				Location string `bun:"location"`
+			}
+
+			err = tx.NewSelect().Model((*therapist)(nil)).Column("id", "location").Scan(ctx, &rows)
+			if err != nil {
+				return err
+			}
+
+			for _, row := range rows {
+				point, err := geo.Default().Locate(row.Location)
+				if err != nil {
+					continue
+				}
+
+				_, err = tx.NewUpdate().
+					Model((*therapist)(nil)).
+					Set("latitude = ?", point.Latitude).
+					Set("longitude = ?", point.Longitude).
+					Where("id = ?", row.ID).
+					Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			_, err := tx.ExecContext(ctx, "DROP INDEX IF EXISTS therapists_coordinates_idx")
+			if err != nil {
+				return err
+			}
+
+			for _, column := range []string{"latitude", "longitude"} {
+				_, err := tx.NewDropColumn().Model((*therapist)(nil)).Column(column).Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			statements := []string{
+				`CREATE TABLE IF NOT EXISTS therapist_annotations (
+					therapist_id INTEGER PRIMARY KEY REFERENCES therapists (id) ON DELETE CASCADE,
+					rating INTEGER CHECK (rating BETWEEN 1 AND 5),
+					contact_status VARCHAR NOT NULL DEFAULT 'not_contacted',
+					updated_at TIMESTAMP NOT NULL
+				)`,
+				`CREATE TABLE IF NOT EXISTS notes (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					therapist_id INTEGER NOT NULL REFERENCES therapists (id) ON DELETE CASCADE,
+					body VARCHAR NOT NULL,
+					created_at TIMESTAMP NOT NULL
+				)`,
+				"CREATE INDEX IF NOT EXISTS notes_therapist_id_idx ON notes (therapist_id)",
+				`CREATE TABLE IF NOT EXISTS tags (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					name VARCHAR NOT NULL UNIQUE
+				)`,
+				`CREATE TABLE IF NOT EXISTS therapist_tags (
+					therapist_id INTEGER NOT NULL REFERENCES therapists (id) ON DELETE CASCADE,
+					tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+					PRIMARY KEY (therapist_id, tag_id)
+				)`,
+			}
+
+			for _, statement := range statements {
+				_, err := tx.ExecContext(ctx, statement)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, table := range []string{"therapist_tags", "tags", "notes", "therapist_annotations"} {
+				_, err := tx.NewDropTable().Table(table).IfExists().Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			statements := []string{
+				`CREATE TABLE IF NOT EXISTS shortlists (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					name VARCHAR NOT NULL UNIQUE,
+					created_at TIMESTAMP NOT NULL
+				)`,
+				`CREATE TABLE IF NOT EXISTS shortlist_therapists (
+					shortlist_id INTEGER NOT NULL REFERENCES shortlists (id) ON DELETE CASCADE,
+					therapist_id INTEGER NOT NULL REFERENCES therapists (id) ON DELETE CASCADE,
+					added_at TIMESTAMP NOT NULL,
+					PRIMARY KEY (shortlist_id, therapist_id)
+				)`,
+			}
+
+			for _, statement := range statements {
+				_, err := tx.ExecContext(ctx, statement)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, table := range []string{"shortlist_therapists", "shortlists"} {
+				_, err := tx.NewDropTable().Table(table).IfExists().Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			statements := []string{
+				`CREATE TABLE IF NOT EXISTS saved_searches (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					name VARCHAR NOT NULL UNIQUE,
+					params VARCHAR NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					last_run_at TIMESTAMP
+				)`,
+				`CREATE TABLE IF NOT EXISTS saved_search_matches (
+					saved_search_id INTEGER NOT NULL REFERENCES saved_searches (id) ON DELETE CASCADE,
+					therapist_id INTEGER NOT NULL REFERENCES therapists (id) ON DELETE CASCADE,
+					accepting_appointments BOOLEAN NOT NULL,
+					PRIMARY KEY (saved_search_id, therapist_id)
+				)`,
+			}
+
+			for _, statement := range statements {
+				_, err := tx.ExecContext(ctx, statement)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, table := range []string{"saved_search_matches", "saved_searches"} {
+				_, err := tx.NewDropTable().Table(table).IfExists().Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			_, err := tx.NewAddColumn().Model((*therapist)(nil)).ColumnExpr("source VARCHAR NOT NULL DEFAULT ''").Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Every therapist stored so far was fetched from psychologytoday.com.
+			_, err = tx.NewUpdate().Model((*therapist)(nil)).Set("source = ?", "psychologytoday").Where("1 = 1").Exec(ctx)
+			return err
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropColumn().Model((*therapist)(nil)).Column("source").Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS failed_pages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url VARCHAR NOT NULL UNIQUE,
+			region VARCHAR NOT NULL,
+			source VARCHAR NOT NULL,
+			profile BOOLEAN NOT NULL,
+			error VARCHAR NOT NULL,
+			attempts INTEGER NOT NULL,
+			failed_at TIMESTAMP NOT NULL
+		)`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Table("failed_pages").IfExists().Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS crawls (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			region VARCHAR NOT NULL,
+			source VARCHAR NOT NULL,
+			started_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP
+		)`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS crawl_pages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			crawl_id INTEGER NOT NULL REFERENCES crawls (id) ON DELETE CASCADE,
+			url VARCHAR NOT NULL,
+			profile BOOLEAN NOT NULL,
+			request BLOB,
+			state VARCHAR NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			UNIQUE (crawl_id, url)
+		)`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Table("crawl_pages").IfExists().Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewDropTable().Table("crawls").IfExists().Exec(ctx)
+		return err
+	})
+}
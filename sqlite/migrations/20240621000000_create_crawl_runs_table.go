@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS crawl_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			crawl_id INTEGER REFERENCES crawls (id) ON DELETE SET NULL,
+			region VARCHAR NOT NULL,
+			source VARCHAR NOT NULL,
+			params VARCHAR,
+			started_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP,
+			pages INTEGER NOT NULL DEFAULT 0,
+			failed INTEGER NOT NULL DEFAULT 0,
+			new INTEGER NOT NULL DEFAULT 0,
+			updated INTEGER NOT NULL DEFAULT 0,
+			unchanged INTEGER NOT NULL DEFAULT 0,
+			vanished INTEGER NOT NULL DEFAULT 0,
+			errors VARCHAR,
+			complete BOOLEAN NOT NULL DEFAULT FALSE
+		)`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Table("crawl_runs").IfExists().Exec(ctx)
+		return err
+	})
+}
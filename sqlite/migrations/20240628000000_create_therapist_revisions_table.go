@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS therapist_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			therapist_id INTEGER NOT NULL REFERENCES therapists (id) ON DELETE CASCADE,
+			changed_at TIMESTAMP NOT NULL,
+			changes VARCHAR NOT NULL
+		)`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS therapist_revisions_therapist_id_idx ON therapist_revisions (therapist_id, changed_at)")
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Table("therapist_revisions").IfExists().Exec(ctx)
+		return err
+	})
+}
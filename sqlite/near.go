@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"errors"
+	"math"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/brittonhayes/therapy/geo"
+	"github.com/uptrace/bun"
+)
+
+var ErrInvalidNear = errors.New("near filter requires a zip code or a latitude and longitude, and a positive radius")
+
+// milesPerDegree is the approximate length of one degree of latitude.
+const milesPerDegree = 69.0
+
+// distanceExpr is the haversine distance in miles between a therapist and the
+// point given by its earth radius, latitude, latitude and longitude arguments.
+const distanceExpr = "? * 2 * asin(sqrt(" +
+	"pow(sin(radians(?TableAlias.latitude - ?) / 2), 2) + " +
+	"cos(radians(?)) * cos(radians(?TableAlias.latitude)) * pow(sin(radians(?TableAlias.longitude - ?) / 2), 2)" +
+	"))"
+
+// center resolves the point a near filter is centered on.
+func center(near *api.Near) (geo.Point, error) {
+	if near.Latitude != nil && near.Longitude != nil {
+		return geo.Point{Latitude: *near.Latitude, Longitude: *near.Longitude}, nil
+	}
+
+	if near.Zip != nil {
+		return geo.Default().Lookup(*near.Zip)
+	}
+
+	return geo.Point{}, ErrInvalidNear
+}
+
+// nearFilterQuery restricts the query to therapists within the radius of the
+// near filter and selects their distance.
+func (r *repository) nearFilterQuery(query *bun.SelectQuery, near *api.Near) (*bun.SelectQuery, error) {
+	if near.RadiusMiles <= 0 {
+		return nil, ErrInvalidNear
+	}
+
+	point, err := center(near)
+	if err != nil {
+		return nil, err
+	}
+
+	// A bounding box lets the coordinates index discard most rows before
+	// the exact distance is computed.
+	dlat := near.RadiusMiles / milesPerDegree
+	dlng := near.RadiusMiles / (milesPerDegree * math.Max(math.Cos(point.Latitude*math.Pi/180), 0.01))
+
+	args := []interface{}{geo.EarthRadiusMiles, point.Latitude, point.Latitude, point.Longitude}
+
+	query = query.
+		ColumnExpr(distanceExpr+" AS distance", args...).
+		Where("?TableAlias.latitude BETWEEN ? AND ?", point.Latitude-dlat, point.Latitude+dlat).
+		Where("?TableAlias.longitude BETWEEN ? AND ?", point.Longitude-dlng, point.Longitude+dlng).
+		Where(distanceExpr+" <= ?", append(args, near.RadiusMiles)...)
+
+	return query, nil
+}
+
+// geocode fills in the therapist's coordinates from the zip code in its
+// location when they have not been set already.
+func geocode(therapist *api.Therapist) {
+	if therapist.Latitude != nil && therapist.Longitude != nil {
+		return
+	}
+
+	point, err := geo.Default().Locate(therapist.Location)
+	if err != nil {
+		return
+	}
+
+	therapist.Latitude = &point.Latitude
+	therapist.Longitude = &point.Longitude
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrOrderRequiresNear   = errors.New("sorting by distance requires a near filter")
+	ErrOrderRequiresSearch = errors.New("sorting by relevance requires a search filter")
+)
+
+// orderExprs maps each sortable field to the expression it sorts by. Distance
+// and relevance are columns computed by the near and search filters.
+var orderExprs = map[api.OrderField]string{
+	api.OrderByTitle:       "?TableAlias.title",
+	api.OrderByCredentials: "?TableAlias.credentials",
+	api.OrderByLastSeen:    "?TableAlias.last_seen_at",
+	api.OrderByDistance:    "distance",
+	api.OrderByRelevance:   "relevance",
+}
+
+// orderExpr returns the expression the order sorts by, checking that any
+// computed column it needs is selected by params.
+func orderExpr(order *api.OrderBy, params *api.GetTherapistParams) (string, error) {
+	expr, ok := orderExprs[order.Field]
+	if !ok {
+		return "", errors.New("unsupported order field: " + string(order.Field))
+	}
+
+	switch order.Field {
+	case api.OrderByDistance:
+		if params == nil || params.Near == nil {
+			return "", ErrOrderRequiresNear
+		}
+	case api.OrderByRelevance:
+		if params == nil || !searching(params) {
+			return "", ErrOrderRequiresSearch
+		}
+	}
+
+	return expr, nil
+}
+
+// defaultOrder sorts by distance or relevance when the filters compute them
+// and otherwise leaves results in insertion order.
+func defaultOrder(params *api.GetTherapistParams) *api.OrderBy {
+	switch {
+	case params == nil:
+		return nil
+	case params.Near != nil:
+		return &api.OrderBy{Field: api.OrderByDistance, Direction: api.Ascending}
+	case searching(params):
+		return &api.OrderBy{Field: api.OrderByRelevance, Direction: api.Descending}
+	}
+
+	return nil
+}
+
+// orderQuery sorts the query by the order in params, breaking ties by ID.
+func orderQuery(query *bun.SelectQuery, params *api.GetTherapistParams) (*bun.SelectQuery, error) {
+	order := defaultOrder(params)
+	if params != nil && params.OrderBy != nil {
+		order = params.OrderBy
+	}
+
+	if order == nil {
+		return query, nil
+	}
+
+	expr, err := orderExpr(order, params)
+	if err != nil {
+		return nil, err
+	}
+
+	direction := "ASC"
+	if order.Direction == api.Descending {
+		direction = "DESC"
+	}
+
+	return query.
+		OrderExpr(expr + " " + direction).
+		OrderExpr("?TableAlias.id " + direction), nil
+}
+
+// orderValue returns the value a therapist sorts by, formatted for a cursor.
+func orderValue(t api.Therapist, field api.OrderField) string {
+	switch field {
+	case api.OrderByTitle:
+		return t.Title
+	case api.OrderByCredentials:
+		return t.Credentials
+	case api.OrderByLastSeen:
+		return t.LastSeenAt.UTC().Format(time.RFC3339Nano)
+	case api.OrderByDistance:
+		if t.Distance != nil {
+			return strconv.FormatFloat(*t.Distance, 'g', -1, 64)
+		}
+	case api.OrderByRelevance:
+		if t.Relevance != nil {
+			return strconv.FormatFloat(*t.Relevance, 'g', -1, 64)
+		}
+	}
+
+	return ""
+}
+
+// parseOrderValue converts a cursor value back into the type it is compared
+// with in SQL.
+func parseOrderValue(field api.OrderField, value string) (interface{}, error) {
+	switch field {
+	case api.OrderByLastSeen:
+		return time.Parse(time.RFC3339Nano, value)
+	case api.OrderByDistance, api.OrderByRelevance:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return exactFloat(f), nil
+	}
+
+	return value, nil
+}
+
+// exactFloat writes a float into a query as an integer scaled by a power of
+// two. Queries carry their arguments as text, and SQLite doesn't always parse
+// a decimal back into the same float, so a row could compare unequal to its
+// own cursor and be returned again.
+func exactFloat(f float64) bun.Safe {
+	frac, exp := math.Frexp(f)
+	mantissa := int64(math.Ldexp(frac, 53))
+	return bun.Safe("(" + strconv.FormatInt(mantissa, 10) + " * pow(2, " + strconv.Itoa(exp-53) + "))")
+}
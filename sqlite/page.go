@@ -0,0 +1,207 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidPage   = errors.New("first and last must not be negative and cannot be used together")
+)
+
+// cursor identifies a row's position in a sort order. It carries the value
+// of the sorted column along with the therapist ID that breaks ties.
+type cursor struct {
+	Field api.OrderField `json:"f,omitempty"`
+	Value string         `json:"v,omitempty"`
+	ID    int            `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string, field api.OrderField) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Field != field {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// keysetWhere restricts the query to rows strictly after (or before) the
+// cursor in the sort order.
+func keysetWhere(query *bun.SelectQuery, expr string, c cursor, op string) (*bun.SelectQuery, error) {
+	if expr == "" {
+		return query.Where("?TableAlias.id "+op+" ?", c.ID), nil
+	}
+
+	value, err := parseOrderValue(c.Field, c.Value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return query.Where("("+expr+", ?TableAlias.id) "+op+" (?, ?)", value, c.ID), nil
+}
+
+// FindPage returns a page of therapists matching params using keyset
+// pagination, so pages stay stable as rows are added or removed. The limit
+// and offset in params are ignored.
+func (r *repository) FindPage(ctx context.Context, params *api.GetTherapistParams, page api.Page) (api.TherapistConnection, error) {
+	var connection api.TherapistConnection
+
+	if (page.First != nil && page.Last != nil) || (page.First != nil && *page.First < 0) || (page.Last != nil && *page.Last < 0) {
+		return connection, ErrInvalidPage
+	}
+
+	filters := api.GetTherapistParams{}
+	if params != nil {
+		filters = *params
+	}
+	filters.Limit, filters.Offset = nil, nil
+
+	var therapists []api.Therapist
+
+	query, err := r.therapistFilterQuery(r.db.NewSelect().Model(&therapists), &filters)
+	if err != nil {
+		return connection, err
+	}
+
+	connection.TotalCount, err = query.Count(ctx)
+	if err != nil {
+		return connection, err
+	}
+
+	var (
+		field     api.OrderField
+		expr      string
+		direction = api.Ascending
+	)
+
+	order := defaultOrder(&filters)
+	if filters.OrderBy != nil {
+		order = filters.OrderBy
+	}
+
+	if order != nil {
+		field = order.Field
+		expr, err = orderExpr(order, &filters)
+		if err != nil {
+			return connection, err
+		}
+
+		if order.Direction == api.Descending {
+			direction = api.Descending
+		}
+	}
+
+	// Rows after a cursor come later in the requested order, so the
+	// comparisons flip for descending orders.
+	after, before := ">", "<"
+	if direction == api.Descending {
+		after, before = "<", ">"
+	}
+
+	for _, bound := range []struct {
+		cursor *string
+		op     string
+	}{{page.After, after}, {page.Before, before}} {
+		if bound.cursor == nil {
+			continue
+		}
+
+		c, err := decodeCursor(*bound.cursor, field)
+		if err != nil {
+			return connection, err
+		}
+
+		query, err = keysetWhere(query, expr, c, bound.op)
+		if err != nil {
+			return connection, err
+		}
+	}
+
+	// Paging backwards walks the order in reverse and flips the rows back
+	// once they have been read.
+	backward := page.Last != nil
+	if backward {
+		if direction == api.Ascending {
+			direction = api.Descending
+		} else {
+			direction = api.Ascending
+		}
+	}
+
+	sort := "ASC"
+	if direction == api.Descending {
+		sort = "DESC"
+	}
+	if expr != "" {
+		query = query.OrderExpr(expr + " " + sort)
+	}
+	query = query.OrderExpr("?TableAlias.id " + sort)
+
+	limit := page.First
+	if backward {
+		limit = page.Last
+	}
+	if limit != nil {
+		query = query.Limit(*limit + 1)
+	}
+
+	err = query.Scan(ctx, &therapists)
+	if err != nil {
+		return connection, err
+	}
+
+	more := limit != nil && len(therapists) > *limit
+	if more {
+		therapists = therapists[:*limit]
+	}
+
+	if backward {
+		for i, j := 0, len(therapists)-1; i < j; i, j = i+1, j-1 {
+			therapists[i], therapists[j] = therapists[j], therapists[i]
+		}
+		connection.PageInfo.HasPreviousPage = more
+		connection.PageInfo.HasNextPage = page.Before != nil
+	} else {
+		connection.PageInfo.HasNextPage = more
+		connection.PageInfo.HasPreviousPage = page.After != nil
+	}
+
+	err = r.loadRelations(ctx, therapists)
+	if err != nil {
+		return connection, err
+	}
+
+	connection.Edges = make([]api.TherapistEdge, 0, len(therapists))
+	for _, t := range therapists {
+		connection.Edges = append(connection.Edges, api.TherapistEdge{
+			Node:   t,
+			Cursor: encodeCursor(cursor{Field: field, Value: orderValue(t, field), ID: t.ID}),
+		})
+	}
+
+	if len(connection.Edges) > 0 {
+		start := connection.Edges[0].Cursor
+		end := connection.Edges[len(connection.Edges)-1].Cursor
+		connection.PageInfo.StartCursor = &start
+		connection.PageInfo.EndCursor = &end
+	}
+
+	return connection, nil
+}
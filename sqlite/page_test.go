@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// seedPages saves therapists that all match a search for therapy, with ties
+// in every sortable field so pages have to break them by ID.
+func seedPages(t *testing.T, repo *repository) {
+	t.Helper()
+
+	seen := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	var therapists []api.Therapist
+	for i, statement := range []string{
+		"therapy",
+		"therapy for couples",
+		"therapy for teens, family therapy and group therapy",
+		"art therapy",
+		"therapy therapy",
+		"talk therapy for anxiety and depression in adults",
+		"therapy",
+	} {
+		therapists = append(therapists, api.Therapist{
+			Title:       []string{"Alex", "Sam", "Jane"}[i%3],
+			Credentials: []string{"LMFT", "PhD"}[i%2],
+			Statement:   statement,
+			Link:        fmt.Sprintf("link-%d", i),
+			Latitude:    ptr(47.5 + float64(i%3)/100),
+			Longitude:   ptr(-122.0),
+			LastSeenAt:  seen.Add(time.Duration(i%4) * time.Hour),
+		})
+	}
+
+	if _, err := repo.SaveBatch(context.Background(), therapists); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ids returns the IDs of therapists in order.
+func ids(therapists []api.Therapist) []int {
+	found := make([]int, len(therapists))
+	for i, t := range therapists {
+		found[i] = t.ID
+	}
+	return found
+}
+
+func TestFindPage(t *testing.T) {
+	repo := database(t)
+	seedPages(t, repo)
+	ctx := context.Background()
+
+	t.Run("insertion order", func(t *testing.T) {
+		testPagesForward(t, repo, api.GetTherapistParams{}, []int{1, 2, 3, 4, 5, 6, 7})
+		testPagesBackward(t, repo, api.GetTherapistParams{}, []int{1, 2, 3, 4, 5, 6, 7})
+	})
+
+	search := "therapy"
+	for _, field := range []api.OrderField{api.OrderByTitle, api.OrderByCredentials, api.OrderByLastSeen, api.OrderByDistance, api.OrderByRelevance} {
+		for _, direction := range []api.Direction{api.Ascending, api.Descending} {
+			t.Run(fmt.Sprintf("%s %s", field, direction), func(t *testing.T) {
+				params := api.GetTherapistParams{
+					Search:  &search,
+					Near:    &api.Near{Latitude: ptr(47.5), Longitude: ptr(-122.0), RadiusMiles: 100},
+					OrderBy: &api.OrderBy{Field: field, Direction: direction},
+				}
+
+				all, err := repo.Find(ctx, &params)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := ids(all)
+				if len(want) != 7 {
+					t.Fatalf("found %d therapists, want 7", len(want))
+				}
+
+				testPagesForward(t, repo, params, want)
+				testPagesBackward(t, repo, params, want)
+			})
+		}
+	}
+}
+
+// testPagesForward walks the connection with first and after.
+func testPagesForward(t *testing.T, repo *repository, params api.GetTherapistParams, want []int) {
+	t.Helper()
+
+	var (
+		got   []int
+		after *string
+	)
+	for i := 0; i <= len(want); i++ {
+		page, err := repo.FindPage(context.Background(), &params, api.Page{First: ptr(2), After: after})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if page.TotalCount != len(want) {
+			t.Errorf("got total count %d, want %d", page.TotalCount, len(want))
+		}
+
+		if page.PageInfo.HasPreviousPage != (after != nil) {
+			t.Errorf("page %d has previous page %t", i, page.PageInfo.HasPreviousPage)
+		}
+
+		for _, edge := range page.Edges {
+			got = append(got, edge.Node.ID)
+		}
+
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		after = page.PageInfo.EndCursor
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paging forward got %v, want %v", got, want)
+	}
+}
+
+// testPagesBackward walks the connection with last and before.
+func testPagesBackward(t *testing.T, repo *repository, params api.GetTherapistParams, want []int) {
+	t.Helper()
+
+	var (
+		got    []int
+		before *string
+	)
+	for i := 0; i <= len(want); i++ {
+		page, err := repo.FindPage(context.Background(), &params, api.Page{Last: ptr(2), Before: before})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if page.PageInfo.HasNextPage != (before != nil) {
+			t.Errorf("page %d has next page %t", i, page.PageInfo.HasNextPage)
+		}
+
+		ids := make([]int, 0, len(page.Edges))
+		for _, edge := range page.Edges {
+			ids = append(ids, edge.Node.ID)
+		}
+		got = append(ids, got...)
+
+		if !page.PageInfo.HasPreviousPage {
+			break
+		}
+		before = page.PageInfo.StartCursor
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paging backward got %v, want %v", got, want)
+	}
+}
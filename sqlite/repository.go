@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"strings"
 
 	"log/slog"
@@ -23,10 +24,12 @@ type repository struct {
 }
 
 func NewRepository(connection string, logger *slog.Logger) therapy.Repository {
-	sqldb, err := sql.Open(sqliteshim.ShimName, connection)
-	if err != nil {
-		panic(err)
-	}
+	sqldb := sql.OpenDB(connector{dsn: connection})
+
+	// SQLite allows one writer at a time, and a fetch writes from several
+	// goroutines. Sharing one connection makes them wait their turn rather
+	// than fail with SQLITE_BUSY.
+	sqldb.SetMaxOpenConns(1)
 
 	db := bun.NewDB(sqldb, sqlitedialect.New())
 
@@ -41,6 +44,36 @@ func NewRepository(connection string, logger *slog.Logger) therapy.Repository {
 	}
 }
 
+// connector opens SQLite connections with foreign keys turned on. SQLite
+// ignores them unless each connection asks, and without them deletes wouldn't
+// cascade to child rows.
+type connector struct {
+	dsn string
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := conn.Prepare("PRAGMA foreign_keys = ON")
+	if err == nil {
+		_, err = stmt.Exec(nil)
+		stmt.Close()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return sqliteshim.Driver()
+}
+
 func (r *repository) Init(ctx context.Context) error {
 	r.logger.DebugContext(ctx, "initializing database")
 	return r.m.Init(ctx)
@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+// database returns a repository backed by a new, migrated SQLite database.
+func database(t *testing.T) *repository {
+	t.Helper()
+
+	ctx := context.Background()
+	repo := NewRepository("file:"+filepath.Join(t.TempDir(), "psych.db"), slog.New(slog.NewTextHandler(io.Discard, nil))).(*repository)
+	if err := repo.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	return repo
+}
+
+// titles returns the titles of therapists in order.
+func titles(therapists []api.Therapist) []string {
+	names := make([]string, len(therapists))
+	for i, t := range therapists {
+		names[i] = t.Title
+	}
+	return names
+}
+
+func TestForeignKeys(t *testing.T) {
+	repo := database(t)
+	ctx := context.Background()
+
+	// Every query gets a new connection, so each one has to turn foreign
+	// keys on for itself.
+	repo.db.SetMaxIdleConns(0)
+
+	crawl, err := repo.CreateCrawl(ctx, api.Crawl{Region: "region", Source: "source"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.QueueCrawlPage(ctx, api.CrawlPage{CrawlID: crawl.ID, URL: "page", State: api.PagePending})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.db.NewDelete().Model((*api.Crawl)(nil)).Where("id = ?", crawl.ID).Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := repo.db.NewSelect().Model((*api.CrawlPage)(nil)).Count(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("%d pages left after deleting their crawl, want 0", n)
+	}
+}
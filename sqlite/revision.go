@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+// revision is the changes to a therapist's details found when saving them
+// again.
+type revision struct {
+	bun.BaseModel `bun:"table:therapist_revisions"`
+
+	ID          int               `bun:"id,pk,autoincrement"`
+	TherapistID int               `bun:"therapist_id,notnull"`
+	ChangedAt   time.Time         `bun:"changed_at,notnull"`
+	Changes     []api.FieldChange `bun:"changes"`
+}
+
+// History returns the revisions of a therapist, oldest first. A therapist
+// that was never saved has no revisions.
+func (r *repository) History(ctx context.Context, therapistID int) ([]api.Revision, error) {
+	var revisions []revision
+	err := r.db.NewSelect().
+		Model(&revisions).
+		Where("therapist_id = ?", therapistID).
+		Order("changed_at", "id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]api.Revision, 0, len(revisions))
+	for _, rev := range revisions {
+		history = append(history, api.Revision{
+			ID:          rev.ID,
+			TherapistID: rev.TherapistID,
+			ChangedAt:   rev.ChangedAt,
+			Changes:     rev.Changes,
+		})
+	}
+
+	return history, nil
+}
+
+// diff returns the scraped details that differ between a saved therapist and
+// a newer copy of it. Details saving keeps when they weren't scraped, and when
+// the therapist was seen, aren't compared.
+func diff(saved, therapist api.Therapist) []api.FieldChange {
+	var changes []api.FieldChange
+
+	// Saving keeps these details when the newer copy has them empty.
+	kept := map[string]bool{"location": true, "fees": true}
+
+	details := []struct {
+		field         string
+		before, after interface{}
+	}{
+		{"title", saved.Title, therapist.Title},
+		{"accepting_appointments", saved.AcceptingAppointments, therapist.AcceptingAppointments},
+		{"credentials", saved.Credentials, therapist.Credentials},
+		{"verified", saved.Verified, therapist.Verified},
+		{"statement", saved.Statement, therapist.Statement},
+		{"phone", saved.Phone, therapist.Phone},
+		{"location", saved.Location, therapist.Location},
+		{"fees", saved.Fees, therapist.Fees},
+		{"in_person", saved.InPerson, therapist.InPerson},
+		{"telehealth", saved.Telehealth, therapist.Telehealth},
+		{"source", saved.Source, therapist.Source},
+	}
+
+	for _, d := range details {
+		if d.after == "" && kept[d.field] {
+			continue
+		}
+
+		if d.before != d.after {
+			changes = append(changes, api.FieldChange{
+				Field: d.field,
+				Old:   fmt.Sprint(d.before),
+				New:   fmt.Sprint(d.after),
+			})
+		}
+	}
+
+	for _, a := range attributes {
+		values := *a.field(&therapist)
+		if values == nil || a.userOwned {
+			continue
+		}
+
+		if !sameValues(*a.field(&saved), values) {
+			changes = append(changes, api.FieldChange{
+				Field: a.name,
+				Old:   strings.Join(*a.field(&saved), "; "),
+				New:   strings.Join(values, "; "),
+			})
+		}
+	}
+
+	return changes
+}
+
+// sameValues reports whether two lists hold the same values, in any order.
+func sameValues(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, v := range a {
+		set[v] = true
+	}
+
+	seen := make(map[string]bool, len(b))
+	for _, v := range b {
+		if !set[v] {
+			return false
+		}
+		seen[v] = true
+	}
+
+	return len(seen) == len(set)
+}
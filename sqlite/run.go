@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+var ErrCrawlRunNotFound = errors.New("crawl run not found")
+
+func (r *repository) CreateCrawlRun(ctx context.Context, run api.CrawlRun) (api.CrawlRun, error) {
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+	run.StartedAt = run.StartedAt.UTC()
+
+	_, err := r.db.NewInsert().Model(&run).Returning("id").Exec(ctx)
+	return run, err
+}
+
+// UpdateCrawlRun saves what a run did once it finishes.
+func (r *repository) UpdateCrawlRun(ctx context.Context, run api.CrawlRun) error {
+	run.StartedAt = run.StartedAt.UTC()
+	run.FinishedAt = run.FinishedAt.UTC()
+
+	_, err := r.db.NewUpdate().Model(&run).WherePK().Exec(ctx)
+	return err
+}
+
+func (r *repository) CrawlRun(ctx context.Context, id int) (api.CrawlRun, error) {
+	var run api.CrawlRun
+	err := r.db.NewSelect().Model(&run).Where("id = ?", id).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return run, ErrCrawlRunNotFound
+	}
+
+	return run, err
+}
+
+// CrawlRuns returns the most recent runs first, up to limit runs, or every
+// run if limit isn't positive.
+func (r *repository) CrawlRuns(ctx context.Context, limit int) ([]api.CrawlRun, error) {
+	var runs []api.CrawlRun
+	q := r.db.NewSelect().Model(&runs).Order("started_at DESC", "id DESC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	err := q.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
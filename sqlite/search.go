@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// SnippetStart and SnippetEnd surround matched terms in search snippets.
+	SnippetStart = "<mark>"
+	SnippetEnd   = "</mark>"
+)
+
+// ftsQuery turns free text into an FTS5 query matching every term, quoting
+// each one so punctuation in the input is not parsed as query syntax. A
+// trailing * on a term is kept as a prefix match.
+func ftsQuery(query string) string {
+	terms := make([]string, 0)
+	for _, term := range strings.Fields(query) {
+		prefix := strings.HasSuffix(term, "*")
+		term = strings.TrimRight(term, "*")
+		if term == "" {
+			continue
+		}
+
+		term = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+
+		terms = append(terms, term)
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// searching reports whether params contain usable full-text search terms.
+func searching(params *api.GetTherapistParams) bool {
+	return params.Search != nil && ftsQuery(*params.Search) != ""
+}
+
+// searchFilterQuery restricts the query to therapists matching the search
+// terms and selects their relevance, where higher is a better match.
+func searchFilterQuery(query *bun.SelectQuery, search string) *bun.SelectQuery {
+	return query.
+		ColumnExpr("fts.relevance").
+		Join("JOIN (SELECT rowid AS fts_id, -bm25(therapists_fts) AS relevance FROM therapists_fts WHERE therapists_fts MATCH ?) AS fts", ftsQuery(search)).
+		JoinOn("fts.fts_id = ?TableAlias.id")
+}
+
+// Search returns therapists whose title, credentials, statement or location
+// match every term in query, best matches first.
+func (r *repository) Search(ctx context.Context, query string, limit int) ([]api.SearchResult, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return []api.SearchResult{}, nil
+	}
+
+	var hits []struct {
+		ID      int     `bun:"id"`
+		Snippet string  `bun:"snippet"`
+		Rank    float64 `bun:"rank"`
+	}
+
+	q := r.db.NewSelect().
+		ColumnExpr("rowid AS id").
+		ColumnExpr("snippet(therapists_fts, -1, ?, ?, '…', 16) AS snippet", SnippetStart, SnippetEnd).
+		ColumnExpr("bm25(therapists_fts) AS rank").
+		TableExpr("therapists_fts").
+		Where("therapists_fts MATCH ?", match).
+		OrderExpr("rank")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	err := q.Scan(ctx, &hits)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hits) == 0 {
+		return []api.SearchResult{}, nil
+	}
+
+	ids := make([]int, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+
+	var therapists []api.Therapist
+	err = r.db.NewSelect().Model(&therapists).Where("? IN (?)", bun.Ident("id"), bun.In(ids)).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.loadRelations(ctx, therapists)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[int]api.Therapist, len(therapists))
+	for _, t := range therapists {
+		index[t.ID] = t
+	}
+
+	results := make([]api.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		t, ok := index[hit.ID]
+		if !ok {
+			continue
+		}
+
+		results = append(results, api.SearchResult{
+			Therapist: t,
+			Snippet:   hit.Snippet,
+			Rank:      hit.Rank,
+		})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrShortlistNotFound = errors.New("shortlist not found")
+	ErrShortlistExists   = errors.New("shortlist already exists")
+	ErrEmptyShortlist    = errors.New("shortlist name must not be empty")
+)
+
+func (r *repository) CreateShortlist(ctx context.Context, name string) (api.Shortlist, error) {
+	shortlist := api.Shortlist{
+		Name:      strings.TrimSpace(name),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if shortlist.Name == "" {
+		return shortlist, ErrEmptyShortlist
+	}
+
+	res, err := r.db.NewInsert().
+		Model(&shortlist).
+		On("CONFLICT (name) DO NOTHING").
+		Returning("id").
+		Exec(ctx)
+	if err != nil {
+		return shortlist, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return shortlist, err
+	}
+
+	if n == 0 {
+		return shortlist, ErrShortlistExists
+	}
+
+	return shortlist, nil
+}
+
+// shortlistQuery selects shortlists along with the number of therapists on
+// each.
+func (r *repository) shortlistQuery(shortlists interface{}) *bun.SelectQuery {
+	return r.db.NewSelect().
+		Model(shortlists).
+		ColumnExpr("?TableAlias.*").
+		ColumnExpr("(SELECT COUNT(*) FROM shortlist_therapists AS st WHERE st.shortlist_id = ?TableAlias.id) AS size")
+}
+
+func (r *repository) Shortlist(ctx context.Context, name string) (api.Shortlist, error) {
+	var shortlist api.Shortlist
+	err := r.shortlistQuery(&shortlist).Where("name = ?", strings.TrimSpace(name)).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return shortlist, ErrShortlistNotFound
+	}
+
+	return shortlist, err
+}
+
+func (r *repository) Shortlists(ctx context.Context) ([]api.Shortlist, error) {
+	var shortlists []api.Shortlist
+	err := r.shortlistQuery(&shortlists).Order("name").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return shortlists, nil
+}
+
+// AddToShortlist adds the therapist to the named shortlist. Adding a
+// therapist already on the list does nothing.
+func (r *repository) AddToShortlist(ctx context.Context, name string, therapistID int) error {
+	shortlist, err := r.Shortlist(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err := r.exists(ctx, therapistID); err != nil {
+		return err
+	}
+
+	_, err = r.db.NewRaw("INSERT INTO shortlist_therapists (shortlist_id, therapist_id, added_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		shortlist.ID, therapistID, time.Now().UTC(),
+	).Exec(ctx)
+	return err
+}
+
+func (r *repository) RemoveFromShortlist(ctx context.Context, name string, therapistID int) error {
+	shortlist, err := r.Shortlist(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.NewDelete().
+		TableExpr("shortlist_therapists").
+		Where("shortlist_id = ?", shortlist.ID).
+		Where("therapist_id = ?", therapistID).
+		Exec(ctx)
+	return err
+}
+
+// shortlistFilterQuery restricts the query to therapists on the named
+// shortlist.
+func (r *repository) shortlistFilterQuery(query *bun.SelectQuery, name string) *bun.SelectQuery {
+	return query.Where("EXISTS (SELECT 1 FROM shortlist_therapists AS st JOIN shortlists AS s ON s.id = st.shortlist_id WHERE st.therapist_id = ?TableAlias.id AND s.name = ?)", name)
+}
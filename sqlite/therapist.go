@@ -2,28 +2,39 @@ package sqlite
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"time"
 
 	"github.com/brittonhayes/therapy/api"
 	"github.com/uptrace/bun"
 )
 
+var ErrMissingLink = errors.New("therapist is missing a profile link")
+
 func (r *repository) therapistFilterQuery(query *bun.SelectQuery, params *api.GetTherapistParams) (*bun.SelectQuery, error) {
 	if params == nil {
 		return query, nil
 	}
 
-	if params.Limit != nil {
-		query = query.Limit(*params.Limit)
+	// Computed columns replace the default column list, so select the
+	// therapist's own columns explicitly alongside them.
+	if params.Near != nil || searching(params) {
+		query = query.ColumnExpr("?TableAlias.*")
 	}
 
-	if params.Offset != nil {
-		query = query.Offset(*params.Offset)
+	if searching(params) {
+		query = searchFilterQuery(query, *params.Search)
 	}
 
 	if params.Title != nil {
 		query.Where("? LIKE ?", bun.Ident("title"), *params.Title+"%")
 	}
 
+	if params.AcceptingAppointments != nil {
+		query.Where("? = ?", bun.Ident("accepting_appointments"), *params.AcceptingAppointments)
+	}
+
 	if params.Credentials != nil {
 		query.Where("? LIKE ?", bun.Ident("credentials"), "%"+*params.Credentials+"%")
 	}
@@ -44,16 +55,321 @@ func (r *repository) therapistFilterQuery(query *bun.SelectQuery, params *api.Ge
 		query.Where("? LIKE ?", bun.Ident("location"), "%"+*params.Location+"%")
 	}
 
+	if params.Link != nil {
+		query.Where("? = ?", bun.Ident("link"), *params.Link)
+	}
+
+	if params.Near != nil {
+		var err error
+		query, err = r.nearFilterQuery(query, params.Near)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if params.InPerson != nil {
+		query.Where("? = ?", bun.Ident("in_person"), *params.InPerson)
+	}
+
+	if params.Telehealth != nil {
+		query.Where("? = ?", bun.Ident("telehealth"), *params.Telehealth)
+	}
+
+	if params.Region != nil {
+		query.Where("? = ?", bun.Ident("region"), *params.Region)
+	}
+
+	if params.Source != nil {
+		query.Where("? = ?", bun.Ident("source"), *params.Source)
+	}
+
+	if params.Stale != nil {
+		query.Where("? = ?", bun.Ident("stale"), *params.Stale)
+	}
+
+	if params.FirstSeenAfter != nil {
+		query.Where("? >= ?", bun.Ident("first_seen_at"), params.FirstSeenAfter.UTC())
+	}
+
+	if params.FirstSeenBefore != nil {
+		query.Where("? < ?", bun.Ident("first_seen_at"), params.FirstSeenBefore.UTC())
+	}
+
+	if params.LastSeenAfter != nil {
+		query.Where("? >= ?", bun.Ident("last_seen_at"), params.LastSeenAfter.UTC())
+	}
+
+	if params.LastSeenBefore != nil {
+		query.Where("? < ?", bun.Ident("last_seen_at"), params.LastSeenBefore.UTC())
+	}
+
+	query = r.attributeFilterQuery(query, params)
+	query = r.annotationFilterQuery(query, params)
+
+	if params.Shortlist != nil {
+		query = r.shortlistFilterQuery(query, *params.Shortlist)
+	}
+
 	return query, nil
 }
 
-func (r *repository) Save(ctx context.Context, therapist api.Therapist) error {
-	_, err := r.db.NewInsert().Model(&therapist).Exec(ctx)
+// loadRelations populates the details of the given therapists that are
+// stored outside of the therapists table.
+func (r *repository) loadRelations(ctx context.Context, therapists []api.Therapist) error {
+	err := r.loadAttributes(ctx, therapists)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return r.loadAnnotations(ctx, therapists)
+}
+
+// Save inserts the therapist or, if a therapist with the same profile link
+// already exists, refreshes the existing row in place so its ID and first seen
+// time are preserved. Saving a therapist always clears its stale flag, and a
+// therapist saved without a region, location or fees keeps the ones it already
+// had. A therapist whose profile wasn't read keeps all of its profile details,
+// and one with Fields set keeps every detail it doesn't name.
+func (r *repository) Save(ctx context.Context, therapist api.Therapist) error {
+	_, err := r.SaveBatch(ctx, []api.Therapist{therapist})
+	return err
+}
+
+// SaveBatch saves therapists the same way as Save, with a single bulk upsert
+// in one transaction, and counts how it changed them. The changed details of
+// therapists already saved are kept as revisions. If a profile link appears
+// more than once, the last therapist with it is saved.
+func (r *repository) SaveBatch(ctx context.Context, therapists []api.Therapist) (api.SaveResult, error) {
+	var result api.SaveResult
+
+	batch := make([]api.Therapist, 0, len(therapists))
+	index := make(map[string]int, len(therapists))
+	for _, therapist := range therapists {
+		if therapist.Link == "" {
+			return result, ErrMissingLink
+		}
+
+		if therapist.LastSeenAt.IsZero() {
+			therapist.LastSeenAt = time.Now()
+		}
+		therapist.LastSeenAt = therapist.LastSeenAt.UTC()
+
+		if therapist.FirstSeenAt.IsZero() {
+			therapist.FirstSeenAt = therapist.LastSeenAt
+		}
+		therapist.FirstSeenAt = therapist.FirstSeenAt.UTC()
+		therapist.Stale = false
+		geocode(&therapist)
+
+		// SQLite can't upsert the same row twice in one statement.
+		if i, ok := index[therapist.Link]; ok {
+			batch[i] = therapist
+			continue
+		}
+		index[therapist.Link] = len(batch)
+		batch = append(batch, therapist)
+	}
+
+	if len(batch) == 0 {
+		return result, nil
+	}
+
+	saved, err := r.savedByLink(ctx, batch)
+	if err != nil {
+		return result, err
+	}
+
+	var revisions []revision
+	for i, therapist := range batch {
+		old, ok := saved[therapist.Link]
+		if !ok {
+			result.New++
+			continue
+		}
+
+		if therapist.Fields != nil {
+			keepFields(&therapist, old)
+			batch[i] = therapist
+		}
+
+		if !therapist.Profiled {
+			therapist.Statement = old.Statement
+			therapist.Location = old.Location
+			therapist.Fees = old.Fees
+			therapist.InPerson = old.InPerson
+			therapist.Telehealth = old.Telehealth
+			batch[i] = therapist
+		}
+
+		changes := diff(old, therapist)
+		if len(changes) == 0 {
+			result.Unchanged++
+			continue
+		}
+
+		result.Updated++
+		revisions = append(revisions, revision{
+			TherapistID: old.ID,
+			ChangedAt:   therapist.LastSeenAt,
+			Changes:     changes,
+		})
+	}
+
+	err = r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(&batch).
+			On("CONFLICT (link) DO UPDATE").
+			Set("title = EXCLUDED.title").
+			Set("accepting_appointments = EXCLUDED.accepting_appointments").
+			Set("credentials = EXCLUDED.credentials").
+			Set("verified = EXCLUDED.verified").
+			Set("statement = EXCLUDED.statement").
+			Set("phone = EXCLUDED.phone").
+			Set("location = COALESCE(NULLIF(EXCLUDED.location, ''), ?TableAlias.location)").
+			Set("fees = COALESCE(NULLIF(EXCLUDED.fees, ''), ?TableAlias.fees)").
+			Set("in_person = EXCLUDED.in_person").
+			Set("telehealth = EXCLUDED.telehealth").
+			Set("latitude = EXCLUDED.latitude").
+			Set("longitude = EXCLUDED.longitude").
+			Set("region = COALESCE(NULLIF(EXCLUDED.region, ''), ?TableAlias.region)").
+			Set("source = EXCLUDED.source").
+			Set("last_seen_at = EXCLUDED.last_seen_at").
+			Set("stale = EXCLUDED.stale").
+			Returning("NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		// SQLite doesn't promise to return the upserted rows in the order
+		// they were given, so their IDs are read back by link instead.
+		links := make([]string, len(batch))
+		for i, therapist := range batch {
+			links[i] = therapist.Link
+		}
+
+		var rows []struct {
+			ID   int
+			Link string
+		}
+		err = tx.NewSelect().
+			Model((*api.Therapist)(nil)).
+			Column("id", "link").
+			Where("link IN (?)", bun.In(links)).
+			Scan(ctx, &rows)
+		if err != nil {
+			return err
+		}
+
+		ids := make(map[string]int, len(rows))
+		for _, row := range rows {
+			ids[row.Link] = row.ID
+		}
+
+		for i := range batch {
+			batch[i].ID = ids[batch[i].Link]
+			err := r.saveAttributes(ctx, tx, &batch[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(revisions) > 0 {
+			_, err = tx.NewInsert().Model(&revisions).Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return api.SaveResult{}, err
+	}
+
+	return result, nil
+}
+
+// columns copy each therapist detail stored in the therapists table, by its
+// JSON name.
+var columns = map[string]func(dst *api.Therapist, src api.Therapist){
+	"title":                  func(dst *api.Therapist, src api.Therapist) { dst.Title = src.Title },
+	"accepting_appointments": func(dst *api.Therapist, src api.Therapist) { dst.AcceptingAppointments = src.AcceptingAppointments },
+	"credentials":            func(dst *api.Therapist, src api.Therapist) { dst.Credentials = src.Credentials },
+	"verified":               func(dst *api.Therapist, src api.Therapist) { dst.Verified = src.Verified },
+	"statement":              func(dst *api.Therapist, src api.Therapist) { dst.Statement = src.Statement },
+	"phone":                  func(dst *api.Therapist, src api.Therapist) { dst.Phone = src.Phone },
+	"location":               func(dst *api.Therapist, src api.Therapist) { dst.Location = src.Location },
+	"fees":                   func(dst *api.Therapist, src api.Therapist) { dst.Fees = src.Fees },
+	"in_person":              func(dst *api.Therapist, src api.Therapist) { dst.InPerson = src.InPerson },
+	"telehealth":             func(dst *api.Therapist, src api.Therapist) { dst.Telehealth = src.Telehealth },
+	"region":                 func(dst *api.Therapist, src api.Therapist) { dst.Region = src.Region },
+}
+
+// keepFields restores the details of a therapist that weren't read from the
+// saved copy of it. Lists that weren't read are already nil, which saving
+// skips.
+func keepFields(therapist *api.Therapist, saved api.Therapist) {
+	for name, keep := range columns {
+		if !slices.Contains(therapist.Fields, name) {
+			keep(therapist, saved)
+		}
+	}
+
+	// Coordinates are geocoded from the location.
+	if !slices.Contains(therapist.Fields, "location") {
+		therapist.Latitude, therapist.Longitude = saved.Latitude, saved.Longitude
+	}
+}
+
+// savedByLink returns the therapists already saved with the profile links of
+// the given therapists, by link.
+func (r *repository) savedByLink(ctx context.Context, therapists []api.Therapist) (map[string]api.Therapist, error) {
+	links := make([]string, 0, len(therapists))
+	for _, t := range therapists {
+		links = append(links, t.Link)
+	}
+
+	var saved []api.Therapist
+	err := r.db.NewSelect().Model(&saved).Where("link IN (?)", bun.In(links)).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.loadAttributes(ctx, saved)
+	if err != nil {
+		return nil, err
+	}
+
+	byLink := make(map[string]api.Therapist, len(saved))
+	for _, t := range saved {
+		byLink[t.Link] = t
+	}
+
+	return byLink, nil
+}
+
+// MarkStale flags every therapist last seen in region before the given time
+// as stale. It should be called once a fetch of the region has completed so
+// that profiles which dropped off the listings are kept but marked inactive.
+func (r *repository) MarkStale(ctx context.Context, region string, before time.Time) (int, error) {
+	res, err := r.db.NewUpdate().
+		Model((*api.Therapist)(nil)).
+		Set("stale = ?", true).
+		Where("? = ?", bun.Ident("region"), region).
+		Where("? < ?", bun.Ident("last_seen_at"), before.UTC()).
+		Where("? = ?", bun.Ident("stale"), false).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
 }
 
 func (r *repository) Find(ctx context.Context, params *api.GetTherapistParams) ([]api.Therapist, error) {
@@ -64,11 +380,31 @@ func (r *repository) Find(ctx context.Context, params *api.GetTherapistParams) (
 		return nil, err
 	}
 
+	query, err = orderQuery(query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		if params.Limit != nil {
+			query = query.Limit(*params.Limit)
+		}
+
+		if params.Offset != nil {
+			query = query.Offset(*params.Offset)
+		}
+	}
+
 	err = query.Scan(ctx, &therapists)
 	if err != nil {
 		return nil, err
 	}
 
+	err = r.loadRelations(ctx, therapists)
+	if err != nil {
+		return nil, err
+	}
+
 	return therapists, nil
 }
 
@@ -79,5 +415,10 @@ func (r *repository) List(ctx context.Context) ([]api.Therapist, error) {
 		return nil, err
 	}
 
+	err = r.loadRelations(ctx, therapists)
+	if err != nil {
+		return nil, err
+	}
+
 	return therapists, nil
 }
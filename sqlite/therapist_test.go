@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/brittonhayes/therapy/api"
+)
+
+func TestSavePartialFields(t *testing.T) {
+	repo := database(t)
+	ctx := context.Background()
+
+	_, err := repo.SaveBatch(ctx, []api.Therapist{{
+		Title:                 "Jane Doe",
+		Link:                  "jane",
+		Credentials:           "LMFT",
+		Phone:                 "(425) 555-0101",
+		Statement:             "Couples and families.",
+		AcceptingAppointments: true,
+		Specialties:           []string{"Family Conflict"},
+		Profiled:              true,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A file with only some columns updates just those.
+	result, err := repo.SaveBatch(ctx, []api.Therapist{{
+		Title:    "Jane Doe, LMFT",
+		Link:     "jane",
+		Profiled: true,
+		Fields:   []string{"title", "link"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Updated != 1 {
+		t.Errorf("got %+v, want 1 updated", result)
+	}
+
+	found, err := repo.Find(ctx, &api.GetTherapistParams{})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("found %d therapists: %v", len(found), err)
+	}
+
+	jane := found[0]
+	if jane.Title != "Jane Doe, LMFT" {
+		t.Errorf("got title %q, want it updated", jane.Title)
+	}
+	if jane.Credentials != "LMFT" || jane.Phone != "(425) 555-0101" || jane.Statement != "Couples and families." || !jane.AcceptingAppointments || len(jane.Specialties) != 1 {
+		t.Errorf("got %+v, want the details missing from the second save kept", jane)
+	}
+
+	history, err := repo.History(ctx, jane.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 1 || len(history[0].Changes) != 1 || history[0].Changes[0].Field != "title" {
+		t.Errorf("got history %+v, want one revision changing the title", history)
+	}
+}
+
+func TestSaveBatchAttributes(t *testing.T) {
+	repo := database(t)
+	ctx := context.Background()
+
+	_, err := repo.SaveBatch(ctx, []api.Therapist{{Title: "Sam", Link: "sam", Specialties: []string{"Grief"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Therapists already saved are updated in place among new ones, and
+	// each keeps its own specialties.
+	_, err = repo.SaveBatch(ctx, []api.Therapist{
+		{Title: "Alex", Link: "alex", Specialties: []string{"Trauma"}},
+		{Title: "Sam", Link: "sam", Specialties: []string{"Grief", "Anxiety"}},
+		{Title: "Jane", Link: "jane", Specialties: []string{"Couples"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := repo.Find(ctx, &api.GetTherapistParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{"Sam": {"Anxiety", "Grief"}, "Alex": {"Trauma"}, "Jane": {"Couples"}}
+	for _, therapist := range found {
+		sort.Strings(therapist.Specialties)
+		if !reflect.DeepEqual(therapist.Specialties, want[therapist.Title]) {
+			t.Errorf("%s has specialties %v, want %v", therapist.Title, therapist.Specialties, want[therapist.Title])
+		}
+	}
+
+	if len(found) != 3 {
+		t.Errorf("found %d therapists, want 3", len(found))
+	}
+}
@@ -0,0 +1,193 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/brittonhayes/therapy/api"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+	ErrSavedSearchExists   = errors.New("saved search already exists")
+	ErrEmptySavedSearch    = errors.New("saved search name must not be empty")
+)
+
+// searchMatch is a therapist that matched a saved search the last time it
+// was run.
+type searchMatch struct {
+	bun.BaseModel `bun:"table:saved_search_matches"`
+
+	SavedSearchID         int  `bun:"saved_search_id,pk"`
+	TherapistID           int  `bun:"therapist_id,pk"`
+	AcceptingAppointments bool `bun:"accepting_appointments,notnull"`
+}
+
+// CreateSavedSearch saves params under name and records its current matches,
+// so the first run only reports changes made after it was created.
+func (r *repository) CreateSavedSearch(ctx context.Context, name string, params api.GetTherapistParams) (api.SavedSearch, error) {
+	search := api.SavedSearch{
+		Name:      strings.TrimSpace(name),
+		Params:    params,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if search.Name == "" {
+		return search, ErrEmptySavedSearch
+	}
+
+	// Reject params the query layer can't run before saving them.
+	_, err := r.Find(ctx, &params)
+	if err != nil {
+		return search, err
+	}
+
+	res, err := r.db.NewInsert().
+		Model(&search).
+		On("CONFLICT (name) DO NOTHING").
+		Returning("id").
+		Exec(ctx)
+	if err != nil {
+		return search, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return search, err
+	}
+
+	if n == 0 {
+		return search, ErrSavedSearchExists
+	}
+
+	_, err = r.RunSavedSearch(ctx, search.Name)
+	if err != nil {
+		return search, err
+	}
+
+	return r.SavedSearch(ctx, search.Name)
+}
+
+func (r *repository) SavedSearch(ctx context.Context, name string) (api.SavedSearch, error) {
+	var search api.SavedSearch
+	err := r.db.NewSelect().Model(&search).Where("name = ?", strings.TrimSpace(name)).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return search, ErrSavedSearchNotFound
+	}
+
+	return search, err
+}
+
+func (r *repository) SavedSearches(ctx context.Context) ([]api.SavedSearch, error) {
+	var searches []api.SavedSearch
+	err := r.db.NewSelect().Model(&searches).Order("name").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return searches, nil
+}
+
+// RunSavedSearch finds the current matches of the named saved search and
+// compares them to the matches from its previous run. Stale therapists are
+// treated as no longer matching unless the search filters on stale itself.
+func (r *repository) RunSavedSearch(ctx context.Context, name string) (api.SearchDiff, error) {
+	diff := api.SearchDiff{
+		Search:  name,
+		Added:   []api.Therapist{},
+		Removed: []api.Therapist{},
+		Changed: []api.AcceptingChange{},
+	}
+
+	search, err := r.SavedSearch(ctx, name)
+	if err != nil {
+		return diff, err
+	}
+
+	params := search.Params
+	if params.Stale == nil {
+		stale := false
+		params.Stale = &stale
+	}
+
+	matches, err := r.Find(ctx, &params)
+	if err != nil {
+		return diff, err
+	}
+
+	var previous []searchMatch
+	err = r.db.NewSelect().Model(&previous).Where("saved_search_id = ?", search.ID).Scan(ctx)
+	if err != nil {
+		return diff, err
+	}
+
+	was := make(map[int]bool, len(previous))
+	for _, m := range previous {
+		was[m.TherapistID] = m.AcceptingAppointments
+	}
+
+	current := make([]searchMatch, 0, len(matches))
+	for _, t := range matches {
+		accepting, ok := was[t.ID]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, t)
+		case accepting != t.AcceptingAppointments:
+			diff.Changed = append(diff.Changed, api.AcceptingChange{Therapist: t, Was: accepting})
+		}
+
+		delete(was, t.ID)
+		current = append(current, searchMatch{
+			SavedSearchID:         search.ID,
+			TherapistID:           t.ID,
+			AcceptingAppointments: t.AcceptingAppointments,
+		})
+	}
+
+	if len(was) > 0 {
+		ids := make([]int, 0, len(was))
+		for id := range was {
+			ids = append(ids, id)
+		}
+
+		err = r.db.NewSelect().Model(&diff.Removed).Where("id IN (?)", bun.In(ids)).Order("id").Scan(ctx)
+		if err != nil {
+			return diff, err
+		}
+
+		err = r.loadRelations(ctx, diff.Removed)
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	err = r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().Model((*searchMatch)(nil)).Where("saved_search_id = ?", search.ID).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(current) > 0 {
+			_, err = tx.NewInsert().Model(&current).Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*api.SavedSearch)(nil)).
+			Set("last_run_at = ?", time.Now().UTC()).
+			Where("id = ?", search.ID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return diff, err
+	}
+
+	return diff, nil
+}
@@ -3,14 +3,56 @@ package therapy
 
 import (
 	"context"
+	"time"
 
 	"github.com/brittonhayes/therapy/api"
 )
 
 type Repository interface {
 	Save(ctx context.Context, therapist api.Therapist) error
+	SaveBatch(ctx context.Context, therapists []api.Therapist) (api.SaveResult, error)
+	MarkStale(ctx context.Context, region string, before time.Time) (int, error)
 	Find(ctx context.Context, therapist *api.GetTherapistParams) ([]api.Therapist, error)
+	FindPage(ctx context.Context, params *api.GetTherapistParams, page api.Page) (api.TherapistConnection, error)
 	List(ctx context.Context) ([]api.Therapist, error)
+	Search(ctx context.Context, query string, limit int) ([]api.SearchResult, error)
+	Get(ctx context.Context, id int) (api.Therapist, error)
+	History(ctx context.Context, therapistID int) ([]api.Revision, error)
+
+	AddNote(ctx context.Context, therapistID int, body string) (api.Note, error)
+	DeleteNote(ctx context.Context, id int) error
+	AddTag(ctx context.Context, therapistID int, tag string) error
+	RemoveTag(ctx context.Context, therapistID int, tag string) error
+	SetRating(ctx context.Context, therapistID int, rating *int) error
+	SetContactStatus(ctx context.Context, therapistID int, status api.ContactStatus) error
+
+	CreateShortlist(ctx context.Context, name string) (api.Shortlist, error)
+	Shortlist(ctx context.Context, name string) (api.Shortlist, error)
+	Shortlists(ctx context.Context) ([]api.Shortlist, error)
+	AddToShortlist(ctx context.Context, name string, therapistID int) error
+	RemoveFromShortlist(ctx context.Context, name string, therapistID int) error
+
+	CreateSavedSearch(ctx context.Context, name string, params api.GetTherapistParams) (api.SavedSearch, error)
+	SavedSearch(ctx context.Context, name string) (api.SavedSearch, error)
+	SavedSearches(ctx context.Context) ([]api.SavedSearch, error)
+	RunSavedSearch(ctx context.Context, name string) (api.SearchDiff, error)
+
+	SaveFailedPage(ctx context.Context, page api.FailedPage) error
+	FailedPages(ctx context.Context) ([]api.FailedPage, error)
+	DeleteFailedPage(ctx context.Context, url string) error
+
+	CreateCrawl(ctx context.Context, crawl api.Crawl) (api.Crawl, error)
+	UnfinishedCrawl(ctx context.Context, region string) (api.Crawl, error)
+	FinishCrawl(ctx context.Context, id int) error
+	QueueCrawlPage(ctx context.Context, page api.CrawlPage) error
+	SetCrawlPageState(ctx context.Context, crawlID int, url string, state api.PageState) error
+	PendingCrawlPages(ctx context.Context, crawlID int, profile bool, after int, limit int) ([]api.CrawlPage, error)
+	CountPendingCrawlPages(ctx context.Context, crawlID int, profile bool, after int) (int, error)
+
+	CreateCrawlRun(ctx context.Context, run api.CrawlRun) (api.CrawlRun, error)
+	UpdateCrawlRun(ctx context.Context, run api.CrawlRun) error
+	CrawlRun(ctx context.Context, id int) (api.CrawlRun, error)
+	CrawlRuns(ctx context.Context, limit int) ([]api.CrawlRun, error)
 
 	Init(ctx context.Context) error
 	Generate(ctx context.Context, name string) error
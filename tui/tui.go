@@ -6,9 +6,11 @@ package tui
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/brittonhayes/therapy/api"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -24,12 +26,46 @@ var bannerStyle = lipgloss.NewStyle().
 
 var focusedStyle = lipgloss.NewStyle().Width(120).Padding(1).Faint(true)
 
+// column is a table column that rows can be sorted by.
+type column struct {
+	title string
+	width int
+	field api.OrderField
+	value func(t api.Therapist) string
+}
+
+var columns = []column{
+	{title: "Name", width: 25, field: api.OrderByTitle, value: func(t api.Therapist) string { return t.Title }},
+	{title: "Phone", width: 15, value: func(t api.Therapist) string { return t.Phone }},
+	{title: "Credentials", width: 40, field: api.OrderByCredentials, value: func(t api.Therapist) string { return t.Credentials }},
+	{title: "Last Seen", width: 12, field: api.OrderByLastSeen, value: func(t api.Therapist) string { return t.LastSeenAt.Format("2006-01-02") }},
+}
+
+const help = "Sort by column: 1-4"
+
+// AddFunc adds a therapist to the shortlist with the given name.
+type AddFunc func(shortlist string, therapist api.Therapist) error
+
+// addedMsg reports the result of adding a therapist to a shortlist.
+type addedMsg struct {
+	shortlist string
+	therapist api.Therapist
+	err       error
+}
+
 type model struct {
-	banner    string
-	title     string
-	statement string
-	Viewport  viewport.Model
-	Table     table.Model
+	banner     string
+	title      string
+	statement  string
+	therapists []api.Therapist
+	sortColumn int
+	descending bool
+	add        AddFunc
+	shortlist  string
+	prompting  bool
+	Prompt     textinput.Model
+	Viewport   viewport.Model
+	Table      table.Model
 }
 
 func (m model) Init() tea.Cmd { return nil }
@@ -37,7 +73,18 @@ func (m model) Init() tea.Cmd { return nil }
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case addedMsg:
+		if msg.err != nil {
+			m.banner = fmt.Sprintf("Could not add %s to %s: %v", msg.therapist.Title, msg.shortlist, msg.err)
+		} else {
+			m.banner = fmt.Sprintf("Added %s to %s", msg.therapist.Title, msg.shortlist)
+		}
+		return m, nil
 	case tea.KeyMsg:
+		if m.prompting {
+			return m.updatePrompt(msg)
+		}
+
 		switch msg.String() {
 		case "esc":
 			if m.Table.Focused() {
@@ -47,6 +94,23 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "q", "ctrl+c":
 			return m, tea.Quit
+		case "1", "2", "3", "4":
+			i := int(msg.String()[0] - '1')
+			if i == m.sortColumn {
+				m.descending = !m.descending
+			} else {
+				m.sortColumn, m.descending = i, false
+			}
+			m.sort()
+			return m, nil
+		case "a":
+			if m.add == nil || len(m.therapists) == 0 {
+				return m, nil
+			}
+			m.prompting = true
+			m.Prompt.SetValue(m.shortlist)
+			m.Prompt.CursorEnd()
+			return m, m.Prompt.Focus()
 		case "enter":
 			return m, tea.Sequence(
 				tea.ExitAltScreen,
@@ -59,7 +123,88 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// updatePrompt handles keys while asking which shortlist to add the selected
+// therapist to.
+func (m model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.prompting = false
+		m.Prompt.Blur()
+		return m, nil
+	case "enter":
+		name := strings.TrimSpace(m.Prompt.Value())
+		if name == "" {
+			return m, nil
+		}
+
+		m.prompting = false
+		m.Prompt.Blur()
+		m.shortlist = name
+
+		therapist, add := m.therapists[m.Table.Cursor()], m.add
+		return m, func() tea.Msg {
+			return addedMsg{shortlist: name, therapist: therapist, err: add(name, therapist)}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.Prompt, cmd = m.Prompt.Update(msg)
+	return m, cmd
+}
+
+// sort orders the rows by the selected column and marks it in the header.
+func (m *model) sort() {
+	selected := columns[m.sortColumn]
+	less := func(a, b api.Therapist) bool {
+		if selected.field == api.OrderByLastSeen {
+			return a.LastSeenAt.Before(b.LastSeenAt)
+		}
+		return strings.ToLower(selected.value(a)) < strings.ToLower(selected.value(b))
+	}
+
+	sort.SliceStable(m.therapists, func(i, j int) bool {
+		if m.descending {
+			return less(m.therapists[j], m.therapists[i])
+		}
+		return less(m.therapists[i], m.therapists[j])
+	})
+
+	cols := make([]table.Column, 0, len(columns))
+	for i, c := range columns {
+		title := c.title
+		if i == m.sortColumn {
+			if m.descending {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		cols = append(cols, table.Column{Title: title, Width: c.width})
+	}
+
+	rows := make([]table.Row, 0, len(m.therapists))
+	for _, t := range m.therapists {
+		if len(t.Phone) == 0 {
+			t.Phone = "N/A"
+		}
+
+		row := make(table.Row, 0, len(columns))
+		for _, c := range columns {
+			row = append(row, c.value(t))
+		}
+		rows = append(rows, row)
+	}
+
+	m.Table.SetColumns(cols)
+	m.Table.SetRows(rows)
+}
+
 func (m model) bannerView() string {
+	if m.prompting {
+		return m.Prompt.View()
+	}
 	return bannerStyle.Render(m.banner)
 }
 
@@ -76,34 +221,12 @@ func (m model) View() string {
 	return fmt.Sprintf("%s\n%s\n%s\n", m.bannerView(), m.bodyView(), m.footerView())
 }
 
-func Run(therapists []api.Therapist) error {
-
-	columns := []table.Column{
-		{Title: "Name", Width: 25},
-		{Title: "Phone", Width: 15},
-		{Title: "Credentials", Width: 40},
-	}
-	rows := []table.Row{}
-
-	sort.SliceStable(therapists, func(i, j int) bool {
-		return therapists[i].Title < therapists[j].Title
-	})
-
-	for _, t := range therapists {
-		if len(t.Phone) == 0 {
-			t.Phone = "N/A"
-		}
-
-		rows = append(rows, table.Row{
-			t.Title,
-			t.Phone,
-			t.Credentials,
-		})
-	}
+// Run shows the therapists in an interactive table. Pressing a column's
+// number sorts by it, and pressing it again reverses the order. If add is not
+// nil, pressing a prompts for a shortlist to add the selected therapist to.
+func Run(therapists []api.Therapist, add AddFunc) error {
 
 	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
 		table.WithFocused(true),
 		table.WithHeight(7),
 	)
@@ -124,7 +247,22 @@ func Run(therapists []api.Therapist) error {
 
 	t.SetStyles(s)
 
-	m := model{Table: t}
+	p := textinput.New()
+	p.Prompt = "Add to shortlist: "
+	p.Placeholder = "name"
+
+	m := model{
+		banner:     help,
+		therapists: therapists,
+		add:        add,
+		Prompt:     p,
+		Table:      t,
+	}
+	if add != nil {
+		m.banner = help + " | Add to shortlist: a"
+	}
+	m.sort()
+
 	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
 		return err
 	}